@@ -0,0 +1,179 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bassosimone/flagscanner"
+)
+
+// SpanKind is the role of a [Span] within a command line argument.
+type SpanKind int
+
+const (
+	// SpanOptionPrefix is the prefix of an option (e.g., `--` in `--output`).
+	SpanOptionPrefix = SpanKind(iota)
+
+	// SpanOptionName is the name of an option (e.g., `output` in `--output`).
+	SpanOptionName
+
+	// SpanInlineValue is the value following the name of an option within the
+	// same argument, including the delimiter, if any (e.g., `=FILE` in
+	// `--output=FILE` or `FILE` in `-oFILE`).
+	SpanInlineValue
+
+	// SpanSeparateValue is an argument that is the value of the
+	// option preceding it (e.g., `FILE` in `--output FILE`).
+	SpanSeparateValue
+
+	// SpanGroupMember is the single-byte name of an option within a
+	// group of options (e.g., each of `x`, `v`, and `f` in `-xvfFILE`).
+	SpanGroupMember
+
+	// SpanSeparator is the options-arguments separator (e.g., `--`).
+	SpanSeparator
+
+	// SpanPositional is a positional argument.
+	SpanPositional
+
+	// SpanError is an argument that we cannot parse (e.g., an unknown option).
+	SpanError
+)
+
+// spanKindNames maps each [SpanKind] to its name.
+var spanKindNames = map[SpanKind]string{
+	SpanOptionPrefix:  "option-prefix",
+	SpanOptionName:    "option-name",
+	SpanInlineValue:   "inline-value",
+	SpanSeparateValue: "separate-value",
+	SpanGroupMember:   "group-member",
+	SpanSeparator:     "separator",
+	SpanPositional:    "positional",
+	SpanError:         "error",
+}
+
+// String returns the name of the span kind (e.g., `option-name`).
+func (kind SpanKind) String() string {
+	if name, found := spanKindNames[kind]; found {
+		return name
+	}
+	return fmt.Sprintf("SpanKind(%d)", int(kind))
+}
+
+// Span is a range of bytes of a command line argument along with its role.
+type Span struct {
+	// Kind is the role of the bytes.
+	Kind SpanKind
+
+	// Start is the index of the first byte within the argument.
+	Start int
+
+	// End is the index following the last byte within the argument.
+	End int
+}
+
+// Classify returns, for each command line argument, the spans describing the
+// role of its bytes, which is useful to colorize a command line (e.g., inside
+// documentation or terminal user interfaces).
+//
+// Unlike [*Parser.Parse], we do not stop at the first error. Rather, we
+// classify as [SpanError] the arguments we cannot parse, including the value
+// of an option, if any, and we continue with the following arguments. Likewise,
+// we do not stop at early options but rather classify them as options.
+// We do not consider the number of positional arguments an error.
+//
+// We split groups of options into a [SpanGroupMember] span for each
+// option, followed by a [SpanInlineValue] span, if needed (e.g., `-xvfFILE`).
+// A group containing a single option uses [SpanOptionName] instead.
+//
+// This method returns the same errors that [*Parser.Parse] returns for
+// an invalid configuration, in which case the spans are nil.
+//
+// The args MUST NOT include the program name.
+func (px *Parser) Classify(args []string) ([][]Span, error) {
+	// Create the configuration
+	cfg, err := newConfig(px)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse one token at a time and classify the arguments of each step
+	var (
+		spans = make([][]Span, len(args))
+		dx    = newParseDriver(cfg, px.scan(cfg, args))
+	)
+	for dx.more() {
+		step := dx.step()
+
+		// Classify all the consumed arguments as errors on failure
+		if step.err != nil {
+			for _, tok := range step.consumed {
+				spans[tok.Index()] = []Span{{Kind: SpanError, Start: 0, End: len(args[tok.Index()])}}
+			}
+			continue
+		}
+
+		// Classify the positional arguments and the separator
+		for _, value := range step.positionals {
+			kind := SpanPositional
+			if _, ok := value.(ValueOptionsArgumentsSeparator); ok {
+				kind = SpanSeparator
+			}
+			spans[value.Token().Index()] = []Span{{Kind: kind, Start: 0, End: len(args[value.Token().Index()])}}
+		}
+
+		// Classify the options and their separate value, if any
+		if tok, ok := step.consumed[0].(flagscanner.OptionToken); ok && len(step.options) > 0 {
+			spans[tok.Index()] = classifyOptionToken(cfg, tok, step.options)
+			if step.valueOf() != nil {
+				index := step.consumed[1].Index()
+				spans[index] = []Span{{Kind: SpanSeparateValue, Start: 0, End: len(args[index])}}
+			}
+		}
+	}
+
+	// Classify the positional arguments rejected by their slot as errors
+	if len(px.PositionalArguments) > 0 {
+		if tok := errorToken(assignPositionalArguments(px.PositionalArguments, dx.positionals.values)); tok != nil {
+			spans[tok.Index()] = []Span{{Kind: SpanError, Start: 0, End: len(args[tok.Index()])}}
+		}
+	}
+	return spans, nil
+}
+
+// classifyOptionToken returns the spans of an option token given
+// the option values that [*parseDriver.step] has parsed from it.
+func classifyOptionToken(cfg *config, tok flagscanner.OptionToken, values []Value) []Span {
+	var (
+		start = len(tok.Prefix)
+		end   = len(tok.Prefix) + len(tok.Name)
+		spans = []Span{{Kind: SpanOptionPrefix, Start: 0, End: start}}
+	)
+
+	// Standalone and early options have a single name possibly followed by the inline value
+	if option, ok := values[0].(ValueOption); !ok || !option.Option.Type.isGroupable() {
+		if index := strings.Index(tok.Name, cfg.optionValueDelimiter()); index > 0 {
+			spans = append(spans, Span{Kind: SpanOptionName, Start: start, End: start + index})
+			return append(spans, Span{Kind: SpanInlineValue, Start: start + index, End: end})
+		}
+		return append(spans, Span{Kind: SpanOptionName, Start: start, End: end})
+	}
+
+	// Groupable options use a byte each and the remaining bytes are the inline value
+	kind := SpanGroupMember
+	if len(values) == 1 {
+		kind = SpanOptionName
+	}
+	for range values {
+		spans = append(spans, Span{Kind: kind, Start: start, End: start + 1})
+		start++
+	}
+	if start < end {
+		spans = append(spans, Span{Kind: SpanInlineValue, Start: start, End: end})
+	}
+	return spans
+}
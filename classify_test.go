@@ -0,0 +1,199 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanKind_String(t *testing.T) {
+	assert.Equal(t, "group-member", SpanGroupMember.String())
+	assert.Equal(t, "SpanKind(128)", SpanKind(128).String())
+}
+
+func TestParser_Classify(t *testing.T) {
+	newTarParser := func() *Parser {
+		px := NewParser()
+		px.SetMinMaxPositionalArguments(0, math.MaxInt)
+		px.AddOptionWithArgumentNone('x', "extract")
+		px.AddOptionWithArgumentNone('v', "verbose")
+		px.AddOptionWithArgumentRequired('f', "file")
+		px.AddLongOptionWithArgumentOptional("color", "auto")
+		px.AddEarlyOption('h', "help")
+		return px
+	}
+
+	// render formats the spans as `kind:text` strings for comparison.
+	render := func(args []string, spans [][]Span) [][]string {
+		output := [][]string{}
+		for idx, argSpans := range spans {
+			entry := []string{}
+			for _, span := range argSpans {
+				entry = append(entry, span.Kind.String()+":"+args[idx][span.Start:span.End])
+			}
+			output = append(output, entry)
+		}
+		return output
+	}
+
+	type testcase struct {
+		name      string
+		args      []string
+		newParser func() *Parser
+		expect    [][]string
+	}
+
+	cases := []testcase{
+		{
+			name:      "group with inline value",
+			args:      []string{"-xvfFILE"},
+			newParser: newTarParser,
+			expect: [][]string{
+				{"option-prefix:-", "group-member:x", "group-member:v", "group-member:f", "inline-value:FILE"},
+			},
+		},
+
+		{
+			name:      "options with separate and inline values",
+			args:      []string{"-f", "FILE", "--file", "FILE", "--color=never", "--color", "-v"},
+			newParser: newTarParser,
+			expect: [][]string{
+				{"option-prefix:-", "option-name:f"},
+				{"separate-value:FILE"},
+				{"option-prefix:--", "option-name:file"},
+				{"separate-value:FILE"},
+				{"option-prefix:--", "option-name:color", "inline-value:=never"},
+				{"option-prefix:--", "option-name:color"},
+				{"option-prefix:-", "option-name:v"},
+			},
+		},
+
+		{
+			name:      "group with separate value",
+			args:      []string{"-xf", "FILE"},
+			newParser: newTarParser,
+			expect: [][]string{
+				{"option-prefix:-", "group-member:x", "group-member:f"},
+				{"separate-value:FILE"},
+			},
+		},
+
+		{
+			name:      "positional arguments and separator",
+			args:      []string{"a", "-v", "--", "-x", ""},
+			newParser: newTarParser,
+			expect: [][]string{
+				{"positional:a"},
+				{"option-prefix:-", "option-name:v"},
+				{"separator:--"},
+				{"positional:-x"},
+				{"positional:"},
+			},
+		},
+
+		{
+			name:      "we continue after errors",
+			args:      []string{"--nonexistent", "-vq", "--file=x", "--verbose=1", "-x", "-f"},
+			newParser: newTarParser,
+			expect: [][]string{
+				{"error:--nonexistent"},
+				{"error:-vq"},
+				{"option-prefix:--", "option-name:file", "inline-value:=x"},
+				{"error:--verbose=1"},
+				{"option-prefix:-", "option-name:x"},
+				{"error:-f"},
+			},
+		},
+
+		{
+			name:      "early options",
+			args:      []string{"-h", "--help", "--", "--help"},
+			newParser: newTarParser,
+			expect: [][]string{
+				{"option-prefix:-", "option-name:h"},
+				{"option-prefix:--", "option-name:help"},
+				{"separator:--"},
+				{"positional:--help"},
+			},
+		},
+
+		{
+			name: "invalid values consume the separate value",
+			args: []string{"--http", "3", "--http", "2"},
+			newParser: func() *Parser {
+				px := NewParser()
+				px.AddOption(&Option{
+					Prefix:        "--",
+					Name:          "http",
+					Type:          OptionTypeStandaloneArgumentRequired,
+					AllowedValues: []string{"1.1", "2"},
+				})
+				return px
+			},
+			expect: [][]string{
+				{"error:--http"},
+				{"error:3"},
+				{"option-prefix:--", "option-name:http"},
+				{"separate-value:2"},
+			},
+		},
+
+		{
+			name: "positional argument rejected by its slot",
+			args: []string{"x", "-v"},
+			newParser: func() *Parser {
+				px := newTarParser()
+				px.AddPositionalArgument(&PositionalArgument{
+					Name: "FILE",
+					Validator: func(value string) error {
+						return errors.New("invalid")
+					},
+				})
+				return px
+			},
+			expect: [][]string{
+				{"error:x"},
+				{"option-prefix:-", "option-name:v"},
+			},
+		},
+
+		{
+			name: "windows-style options",
+			args: []string{"/out:FILE", "/V"},
+			newParser: func() *Parser {
+				px := NewWindowsParser()
+				px.AddOption(
+					&Option{Prefix: "/", Name: "out", Type: OptionTypeStandaloneArgumentRequired},
+					&Option{Prefix: "/", Name: "v", Type: OptionTypeStandaloneArgumentNone},
+				)
+				return px
+			},
+			expect: [][]string{
+				{"option-prefix:/", "option-name:out", "inline-value::FILE"},
+				{"option-prefix:/", "option-name:V"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			spans, err := tc.newParser().Classify(tc.args)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, render(tc.args, spans))
+		})
+	}
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		px := newTarParser()
+		px.AddOption(&Option{Prefix: "--", Name: "file"})
+		spans, err := px.Classify([]string{"-x"})
+		assert.Error(t, err)
+		assert.Nil(t, spans)
+	})
+}
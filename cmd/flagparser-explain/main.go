@@ -0,0 +1,237 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+// Command flagparser-explain explains how a [*flagparser.Parser] described by a
+// JSON spec (see [flagparser.LoadParserSpecJSON]) parses a command line, which is
+// useful to debug parser specs. For example:
+//
+//	flagparser-explain curl.json https://www.example.com/ -fsSLo index.html
+//
+// We print a table showing, for each argument, the token into which we scanned it,
+// how we parsed it (e.g., as a group of options or as the value of the preceding
+// option), and the position of the parsed values after permutation. Then, we print
+// the parsed values in order. We use [*flagparser.Parser.Explain], which runs
+// the same parsing and permutation code that [*flagparser.Parser.Parse] runs.
+//
+// Use `-` as the spec path to read the spec from the standard input.
+//
+// The exit code is 0 on success, 1 when the command line is invalid,
+// and 2 when our own options or the spec are invalid.
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bassosimone/flagparser"
+	"github.com/bassosimone/flagscanner"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// usage is the help text.
+const usage = `usage: flagparser-explain [-h] [--] SPEC [ARG...]
+
+Explain how the parser described by the JSON SPEC file parses ARG...
+
+Options:
+  -h, --help  print this help and exit
+`
+
+// newCommandParser returns the [*flagparser.Parser] for our own options.
+func newCommandParser() *flagparser.Parser {
+	px := flagparser.NewParser()
+	px.DisablePermute = true
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddEarlyOption('h', "help")
+	return px
+}
+
+// run runs the command and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	// Parse our own command line
+	values, err := newCommandParser().Parse(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "flagparser-explain: %s\n", err.Error())
+		fmt.Fprintf(stderr, "Try 'flagparser-explain --help' for more information.\n")
+		return 2
+	}
+	if len(values) == 1 {
+		if _, ok := values[0].(flagparser.ValueOption); ok {
+			fmt.Fprint(stdout, usage)
+			return 0
+		}
+	}
+	params := parameters(values)
+	if len(params) <= 0 {
+		fmt.Fprintf(stderr, "flagparser-explain: missing spec argument\n")
+		return 2
+	}
+
+	// Load the parser from the spec
+	px, err := loadParser(params[0], stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "flagparser-explain: %s\n", err.Error())
+		return 2
+	}
+
+	// Explain the command line and print the result
+	explanations, err := px.Explain(params[1:])
+	if explanations == nil && err != nil {
+		fmt.Fprintf(stderr, "flagparser-explain: %s\n", err.Error())
+		return 2
+	}
+	printExplanations(stdout, explanations)
+	if err != nil {
+		fmt.Fprintf(stdout, "\nerror: %s\n", err.Error())
+		return 1
+	}
+	fmt.Fprintf(stdout, "\nresult: %s\n", flagparser.QuotePOSIXShell(result(explanations)))
+	return 0
+}
+
+// parameters returns the positional arguments, including the separators
+// except the one terminating our options, which precedes the spec path.
+func parameters(values []flagparser.Value) []string {
+	var params []string
+	for _, value := range values {
+		switch value := value.(type) {
+		case flagparser.ValuePositionalArgument:
+			params = append(params, value.Value)
+
+		case flagparser.ValueOptionsArgumentsSeparator:
+			if len(params) > 0 {
+				params = append(params, value.Separator)
+			}
+		}
+	}
+	return params
+}
+
+// loadParser loads the [*flagparser.Parser] from the JSON spec at the given path.
+func loadParser(path string, stdin io.Reader) (*flagparser.Parser, error) {
+	if path == "-" {
+		return flagparser.LoadParserSpecJSON(stdin)
+	}
+	filep, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer filep.Close()
+	return flagparser.LoadParserSpecJSON(filep)
+}
+
+// printExplanations prints a table explaining each argument.
+func printExplanations(w io.Writer, explanations []flagparser.ArgumentExplanation) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "INDEX\tARGUMENT\tTOKEN\tPARSED AS\tPOSITION")
+	for idx, expl := range explanations {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n",
+			idx, flagparser.QuotePOSIXShell([]string{expl.Arg}), tokenKind(expl.Token), parsedAs(expl), positions(expl))
+	}
+	tw.Flush()
+}
+
+// tokenKind describes the kind of a [flagscanner.Token].
+func tokenKind(tok flagscanner.Token) string {
+	switch tok := tok.(type) {
+	case flagscanner.OptionToken:
+		return fmt.Sprintf("option (prefix %q)", tok.Prefix)
+	case flagscanner.OptionsArgumentsSeparatorToken:
+		return "separator"
+	default:
+		return "positional"
+	}
+}
+
+// parsedAs describes how we parsed the argument.
+func parsedAs(expl flagparser.ArgumentExplanation) string {
+	switch {
+	case expl.ValueOf != nil:
+		return "value of " + expl.ValueOf.Prefix + expl.ValueOf.Name
+	case expl.Err != nil && len(expl.Values) <= 0:
+		return "error: " + expl.Err.Error()
+	case len(expl.Values) <= 0:
+		return "not parsed"
+	}
+	var parts []string
+	for _, value := range expl.Values {
+		parts = append(parts, describeValue(expl.Token, value))
+	}
+	output := strings.Join(parts, ", ")
+	if len(expl.Values) > 1 {
+		output = "group of " + output
+	}
+	if expl.Err != nil {
+		output += ", then error: " + expl.Err.Error()
+	}
+	return output
+}
+
+// describeValue describes a [flagparser.Value] parsed from the given token.
+func describeValue(tok flagscanner.Token, value flagparser.Value) string {
+	switch value := value.(type) {
+	case flagparser.ValueOption:
+		output := "option " + value.Option.Prefix + value.Option.Name
+		switch {
+		case value.Option.Type == flagparser.OptionTypeEarlyArgumentNone:
+			output = "early " + output
+		case value.Explicit:
+			output += fmt.Sprintf(" with value %q", value.Value)
+		case value.Value != "":
+			output += fmt.Sprintf(" with default value %q", value.Value)
+		}
+		return output
+
+	case flagparser.ValuePositionalArgument:
+		output := "positional"
+		if _, ok := tok.(flagscanner.OptionToken); ok {
+			output += " (options terminated)"
+		}
+		if value.Argument != nil {
+			output += " " + value.Argument.Name
+		}
+		return output
+
+	default:
+		return "separator"
+	}
+}
+
+// positions formats the positions of the values after permutation.
+func positions(expl flagparser.ArgumentExplanation) string {
+	if len(expl.Positions) <= 0 {
+		return "-"
+	}
+	var output []string
+	for _, position := range expl.Positions {
+		output = append(output, strconv.Itoa(position))
+	}
+	return strings.Join(output, ",")
+}
+
+// result returns the strings of the parsed values in order.
+func result(explanations []flagparser.ArgumentExplanation) []string {
+	var values []flagparser.Value
+	for _, expl := range explanations {
+		for idx, position := range expl.Positions {
+			for len(values) <= position {
+				values = append(values, nil)
+			}
+			values[position] = expl.Values[idx]
+		}
+	}
+	var output []string
+	for _, value := range values {
+		output = append(output, value.Strings()...)
+	}
+	return output
+}
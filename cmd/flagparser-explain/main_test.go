@@ -0,0 +1,152 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// curlSpec is a JSON spec describing a curl-like parser.
+const curlSpec = `{
+  "max_positional_arguments": -1,
+  "min_positional_arguments": 1,
+  "options_arguments_separator": "--",
+  "options": [
+    {"prefix": "-", "name": "f", "type": "GroupableArgumentNone"},
+    {"prefix": "-", "name": "o", "type": "GroupableArgumentRequired", "alias": "output"},
+    {"prefix": "--", "name": "output", "type": "StandaloneArgumentRequired", "alias": "o"},
+    {"prefix": "-", "name": "s", "type": "GroupableArgumentNone"},
+    {"prefix": "--", "name": "help", "type": "EarlyArgumentNone"}
+  ]
+}`
+
+func Test_run(t *testing.T) {
+	type testcase struct {
+		name     string
+		args     []string
+		stdin    string
+		stdout   string
+		stderr   string
+		exitCode int
+	}
+
+	cases := []testcase{
+		{
+			name:  "permutation, groups, and values",
+			args:  []string{"-", "https://x.com/", "-fso", "a b", "--output=c", "--", "-f"},
+			stdin: curlSpec,
+			stdout: `INDEX  ARGUMENT        TOKEN                 PARSED AS                                                  POSITION
+0      https://x.com/  positional            positional                                                 4
+1      -fso            option (prefix "-")   group of option -f, option -s, option -o with value "a b"  0,1,2
+2      'a b'           positional            value of -o                                                -
+3      --output=c      option (prefix "--")  option --output with value "c"                             3
+4      --              separator             separator                                                  5
+5      -f              option (prefix "-")   positional (options terminated)                            6
+
+result: -f -s -o 'a b' --output c https://x.com/ -- -f
+`,
+		},
+
+		{
+			name:  "early option",
+			args:  []string{"--", "-", "https://x.com/", "--nonexistent", "--help"},
+			stdin: curlSpec,
+			stdout: `INDEX  ARGUMENT        TOKEN                 PARSED AS            POSITION
+0      https://x.com/  positional            not parsed           -
+1      --nonexistent   option (prefix "--")  not parsed           -
+2      --help          option (prefix "--")  early option --help  0
+
+result: --help
+`,
+		},
+
+		{
+			name:  "invalid command line",
+			args:  []string{"-", "https://x.com/", "-f", "-o"},
+			stdin: curlSpec,
+			stdout: `INDEX  ARGUMENT        TOKEN                PARSED AS                               POSITION
+0      https://x.com/  positional           positional                              -
+1      -f              option (prefix "-")  option -f                               -
+2      -o              option (prefix "-")  error: option requires an argument: -o  -
+
+error: option requires an argument: -o
+`,
+			exitCode: 1,
+		},
+
+		{
+			name:  "too few positional arguments",
+			args:  []string{"-", "-f"},
+			stdin: curlSpec,
+			stdout: `INDEX  ARGUMENT  TOKEN                PARSED AS  POSITION
+0      -f        option (prefix "-")  option -f  -
+
+error: too few positional arguments: expected at least 1, got 0
+`,
+			exitCode: 1,
+		},
+
+		{
+			name:   "help",
+			args:   []string{"--help"},
+			stdout: usage,
+		},
+
+		{
+			name:     "missing spec",
+			args:     []string{},
+			stderr:   "flagparser-explain: missing spec argument\n",
+			exitCode: 2,
+		},
+
+		{
+			name:     "invalid option",
+			args:     []string{"-x"},
+			stderr:   "flagparser-explain: unknown option: -x\nTry 'flagparser-explain --help' for more information.\n",
+			exitCode: 2,
+		},
+
+		{
+			name:     "invalid spec",
+			args:     []string{"-", "x"},
+			stdin:    `{"options": [{"prefix": "-", "name": "x", "type": "Groupable"}]}`,
+			stderr:   "flagparser-explain: invalid parser spec: options[0].type: invalid option type name: \"Groupable\"\n",
+			exitCode: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			assert.Equal(t, tc.stdout, stdout.String())
+			assert.Equal(t, tc.stderr, stderr.String())
+			assert.Equal(t, tc.exitCode, exitCode)
+		})
+	}
+
+	t.Run("spec file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "curl.json")
+		assert.NoError(t, os.WriteFile(path, []byte(curlSpec), 0600))
+		var stdout, stderr bytes.Buffer
+		exitCode := run([]string{path, "https://x.com/", "-f"}, strings.NewReader(""), &stdout, &stderr)
+		assert.Equal(t, 0, exitCode)
+		assert.Contains(t, stdout.String(), "result: -f https://x.com/\n")
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("nonexistent spec file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		exitCode := run([]string{filepath.Join(t.TempDir(), "nonexistent.json")}, strings.NewReader(""), &stdout, &stderr)
+		assert.Equal(t, 2, exitCode)
+		assert.Contains(t, stderr.String(), "no such file or directory")
+	})
+}
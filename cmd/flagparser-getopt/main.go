@@ -0,0 +1,213 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+// Command flagparser-getopt normalizes a command line like util-linux getopt(1)
+// using [flagparser.NewGetoptParser], so that shell scripts share the exact
+// semantics of the Go parser. For example:
+//
+//	eval set -- "$(flagparser-getopt -o ab:c:: -l all,file:,color:: -- "$@")"
+//
+// We print the options, each followed by its argument, if any, then `--`, and
+// then the positional arguments, using single quotes for all arguments. Like
+// util-linux getopt, we always print the argument of options with an optional
+// argument, which is empty when missing. Unlike util-linux getopt, we do not
+// print anything on standard output when the command line is invalid.
+//
+// The exit code is 0 on success, 1 when the command line is invalid, 2 when
+// our own options are invalid, and 4 with `-T`, for compatibility.
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/bassosimone/flagparser"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.LookupEnv, os.Stdout, os.Stderr))
+}
+
+// usage is the help text.
+const usage = `usage: flagparser-getopt [-q] [-n NAME] -o OPTSTRING [-l LONGOPTS] -- [ARG...]
+       flagparser-getopt [-q] [-n NAME] [-l LONGOPTS] -- OPTSTRING [ARG...]
+
+Normalize ARG... like util-linux getopt(1).
+
+Options:
+  -h, --help                  print this help and exit
+  -l, --longoptions LONGOPTS  comma separated long options (e.g., all,file:,color::)
+  -n, --name NAME             program name to use when reporting errors
+  -o, --options OPTSTRING     short options using the getopt(3) syntax
+  -q, --quiet                 do not report errors
+  -T, --test                  exit with 4 to identify a getopt(1) compatible program
+`
+
+// newCommandParser returns the [*flagparser.Parser] for our own options.
+func newCommandParser() *flagparser.Parser {
+	px := flagparser.NewParser()
+	px.DisablePermute = true
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddEarlyOption('h', "help")
+	px.AddOptionWithArgumentRequired('l', "longoptions")
+	px.AddOptionWithArgumentRequired('n', "name")
+	px.AddOptionWithArgumentRequired('o', "options")
+	px.AddOptionWithArgumentNone('q', "quiet")
+	px.AddOptionWithArgumentNone('T', "test")
+	return px
+}
+
+// run runs the command and returns the exit code.
+func run(args []string, lookupEnv func(key string) (string, bool), stdout, stderr io.Writer) int {
+	// Parse our own command line
+	values, err := newCommandParser().Parse(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "flagparser-getopt: %s\n", err.Error())
+		fmt.Fprintf(stderr, "Try 'flagparser-getopt --help' for more information.\n")
+		return 2
+	}
+	var (
+		name      = "flagparser-getopt"
+		optstring *string
+		longopts  []flagparser.GetoptLongOption
+		quiet     bool
+	)
+	for _, value := range values {
+		if value, ok := value.(flagparser.ValueOption); ok {
+			switch value.Option.Name {
+			case "h", "help":
+				fmt.Fprint(stdout, usage)
+				return 0
+			case "l", "longoptions":
+				longopts = append(longopts, parseLongOptions(value.Value)...)
+			case "n", "name":
+				name = value.Value
+			case "o", "options":
+				optstring = &value.Value
+			case "q", "quiet":
+				quiet = true
+			case "T", "test":
+				return 4
+			}
+		}
+	}
+	params := positionalArguments(values, true)
+
+	// Like util-linux getopt, use the first parameter as the
+	// optstring when we do not have the `-o` option
+	if optstring == nil {
+		if len(params) <= 0 {
+			fmt.Fprintf(stderr, "flagparser-getopt: missing optstring argument\n")
+			return 2
+		}
+		optstring, params = &params[0], params[1:]
+	}
+
+	// Create the parser for the parameters
+	px, err := flagparser.NewGetoptParser(*optstring, longopts)
+	if err != nil {
+		fmt.Fprintf(stderr, "flagparser-getopt: %s\n", err.Error())
+		return 2
+	}
+	px.LookupEnv = lookupEnv
+
+	// Parse and print the parameters
+	values, err = px.Parse(params)
+	if err != nil {
+		if !quiet {
+			fmt.Fprintf(stderr, "%s: %s\n", name, err.Error())
+		}
+		return 1
+	}
+	_, posixlyCorrect := lookupEnv("POSIXLY_CORRECT")
+	fmt.Fprintln(stdout, formatValues(values, px.DisablePermute || posixlyCorrect))
+	return 0
+}
+
+// parseLongOptions parses comma or space separated long options
+// where the `:` and `::` suffixes indicate the argument kind.
+func parseLongOptions(spec string) (longopts []flagparser.GetoptLongOption) {
+	fields := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	for _, field := range fields {
+		longopt := flagparser.GetoptLongOption{Name: field, HasArg: flagparser.GetoptNoArgument}
+		switch {
+		case strings.HasSuffix(field, "::"):
+			longopt.Name, longopt.HasArg = strings.TrimSuffix(field, "::"), flagparser.GetoptOptionalArgument
+		case strings.HasSuffix(field, ":"):
+			longopt.Name, longopt.HasArg = strings.TrimSuffix(field, ":"), flagparser.GetoptRequiredArgument
+		}
+		longopts = append(longopts, longopt)
+	}
+	return
+}
+
+// formatValues formats the values like util-linux getopt using [flagparser.Value.Strings].
+//
+// Because util-linux getopt prints the argument of options with an optional argument
+// as a separate word, which is empty when missing, we split the argument that Strings
+// glues to these options (e.g., `-cVALUE` and `--color=VALUE`).
+func formatValues(values []flagparser.Value, disablePermute bool) string {
+	var output []string
+	for _, value := range values {
+		option, ok := value.(flagparser.ValueOption)
+		if !ok {
+			continue
+		}
+		var (
+			name  = option.Option.Prefix + option.Option.Name
+			words = value.Strings()
+		)
+		output = append(output, name)
+		switch option.Option.Type {
+		case flagparser.OptionTypeGroupableArgumentOptional:
+			output = append(output, quote(strings.TrimPrefix(words[0], name)))
+		case flagparser.OptionTypeStandaloneArgumentOptional:
+			output = append(output, quote(strings.TrimPrefix(strings.TrimPrefix(words[0], name), "=")))
+		default:
+			for _, word := range words[1:] {
+				output = append(output, quote(word))
+			}
+		}
+	}
+	output = append(output, "--")
+	for _, arg := range positionalArguments(values, disablePermute) {
+		output = append(output, quote(arg))
+	}
+	return " " + strings.Join(output, " ")
+}
+
+// positionalArguments returns the positional arguments, including the separators
+// except the one terminating the options, like getopt_long does.
+//
+// The disablePermute argument indicates whether permutation is disabled, in which
+// case a separator following a positional argument is a positional argument.
+func positionalArguments(values []flagparser.Value, disablePermute bool) []string {
+	var (
+		args       []string
+		terminated bool
+	)
+	for _, value := range values {
+		switch value.(type) {
+		case flagparser.ValuePositionalArgument:
+			args = append(args, value.Strings()...)
+
+		case flagparser.ValueOptionsArgumentsSeparator:
+			if terminated || (disablePermute && len(args) > 0) {
+				args = append(args, value.Strings()...)
+			}
+			terminated = true
+		}
+	}
+	return args
+}
+
+// quote quotes the argument for the shell like util-linux getopt.
+func quote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
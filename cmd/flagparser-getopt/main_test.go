@@ -0,0 +1,141 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_run(t *testing.T) {
+	type testcase struct {
+		name           string
+		args           []string
+		posixlyCorrect bool
+		stdout         string
+		stderr         string
+		exitCode       int
+	}
+
+	// Note: the stdout of the successful cases is what util-linux getopt prints
+	cases := []testcase{
+		{
+			name: "permutation and arguments",
+			args: []string{
+				"-o", "ab:c::", "-l", "verbose,file:,color::", "--",
+				"x", "-a", "-bfoo", "-c", "-cval", "--color=", "--color", "--color==v", "--file", "it's", "y", "--", "-a", "z",
+			},
+			stdout: ` -a -b 'foo' -c '' -c 'val' --color '' --color '' --color '=v' --file 'it'\''s' -- 'x' 'y' '-a' 'z'` + "\n",
+		},
+
+		{
+			name:   "disabled permutation",
+			args:   []string{"-o", "+ab", "--", "x", "-a"},
+			stdout: " -- 'x' '-a'\n",
+		},
+
+		{
+			name:           "POSIXLY_CORRECT",
+			args:           []string{"-o", "ab", "--", "-a", "x", "-b"},
+			posixlyCorrect: true,
+			stdout:         " -a -- 'x' '-b'\n",
+		},
+
+		{
+			name:   "separators",
+			args:   []string{"-o", "ab", "--", "-a", "--", "x", "--", "-b"},
+			stdout: " -a -- 'x' '--' '-b'\n",
+		},
+
+		{
+			name:   "separator following a positional argument",
+			args:   []string{"-o", "+ab", "--", "-a", "x", "--", "-b"},
+			stdout: " -a -- 'x' '--' '-b'\n",
+		},
+
+		{
+			name:   "optstring as the first parameter",
+			args:   []string{"-l", "all,file:", "--", "", "--file=a=b", "--all"},
+			stdout: " --file 'a=b' --all --\n",
+		},
+
+		{
+			name:   "no parameters",
+			args:   []string{"-o", "ab:", "--"},
+			stdout: " --\n",
+		},
+
+		{
+			name:     "invalid parameters",
+			args:     []string{"-o", "ab:", "-n", "foo", "--", "-b"},
+			stderr:   "foo: option requires an argument: -b\n",
+			exitCode: 1,
+		},
+
+		{
+			name:     "invalid parameters with quiet",
+			args:     []string{"-q", "-o", "a", "--", "-b"},
+			exitCode: 1,
+		},
+
+		{
+			name:     "invalid optstring",
+			args:     []string{"-o", "-ab", "--"},
+			stderr:   "flagparser-getopt: invalid getopt spec \"-ab\": returning positional arguments in order is not supported\n",
+			exitCode: 2,
+		},
+
+		{
+			name:     "missing optstring",
+			args:     []string{"-l", "all"},
+			stderr:   "flagparser-getopt: missing optstring argument\n",
+			exitCode: 2,
+		},
+
+		{
+			name:     "invalid options",
+			args:     []string{"-x"},
+			stderr:   "flagparser-getopt: unknown option: -x\nTry 'flagparser-getopt --help' for more information.\n",
+			exitCode: 2,
+		},
+
+		{
+			name:     "test",
+			args:     []string{"-T"},
+			exitCode: 4,
+		},
+
+		{
+			name:   "help",
+			args:   []string{"--help"},
+			stdout: usage,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lookupEnv := func(key string) (string, bool) {
+				return "1", key == "POSIXLY_CORRECT" && tc.posixlyCorrect
+			}
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tc.args, lookupEnv, &stdout, &stderr)
+			assert.Equal(t, tc.exitCode, exitCode)
+			assert.Equal(t, tc.stdout, stdout.String())
+			assert.Equal(t, tc.stderr, stderr.String())
+		})
+	}
+}
+
+func Test_parseLongOptions(t *testing.T) {
+	longopts := parseLongOptions("all, file:\tcolor::")
+	var got []string
+	for _, longopt := range longopts {
+		got = append(got, longopt.Name+strings.Repeat(":", int(longopt.HasArg)))
+	}
+	assert.Equal(t, []string{"all", "file:", "color::"}, got)
+}
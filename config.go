@@ -7,7 +7,11 @@
 package flagparser
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 
 	"github.com/bassosimone/flagscanner"
 )
@@ -51,7 +55,7 @@ var _ error = ErrTooLongGroupableOptionName{}
 
 // Error returns a string representation of this error.
 func (err ErrTooLongGroupableOptionName) Error() string {
-	return fmt.Sprintf("groupable option names should be a single byte, found: %+v", err.Option)
+	return fmt.Sprintf("groupable option names should be a single byte, found: %s", describeOption(err.Option))
 }
 
 // ErrEmptyOptionName indicates that an option name is empty.
@@ -64,7 +68,7 @@ var _ error = ErrEmptyOptionName{}
 
 // Error returns a string representation of this error.
 func (err ErrEmptyOptionName) Error() string {
-	return fmt.Sprintf("option name cannot be empty: %+v", err.Option)
+	return fmt.Sprintf("option name cannot be empty: %s", describeOption(err.Option))
 }
 
 // ErrEmptyOptionPrefix indicates that an option prefix is empty.
@@ -77,7 +81,66 @@ var _ error = ErrEmptyOptionPrefix{}
 
 // Error returns a string representation of this error.
 func (err ErrEmptyOptionPrefix) Error() string {
-	return fmt.Sprintf("option prefix cannot be empty: %+v", err.Option)
+	return fmt.Sprintf("option prefix cannot be empty: %s", describeOption(err.Option))
+}
+
+// ErrInvalidOptionType indicates that an [OptionType] is not one of the
+// allowed values (e.g., [OptionTypeStandaloneArgumentRequired]).
+type ErrInvalidOptionType struct {
+	// Type is the invalid option type.
+	Type OptionType
+
+	// Option is the option with the invalid type, if any.
+	Option *Option
+}
+
+var _ error = ErrInvalidOptionType{}
+
+// Error returns a string representation of this error.
+func (err ErrInvalidOptionType) Error() string {
+	if err.Option == nil {
+		return fmt.Sprintf("invalid option type: %s", err.Type)
+	}
+	return fmt.Sprintf("invalid option type: %s: %s%s", err.Type, err.Option.Prefix, err.Option.Name)
+}
+
+// describeOption returns a string representation of an [*Option] for
+// use in error messages, which contains its prefix, name, and type.
+func describeOption(option *Option) string {
+	return fmt.Sprintf("%s%s (%s)", option.Prefix, option.Name, option.Type)
+}
+
+// ErrSeparateArgumentWithKeepEmptyValue indicates that an option sets both the
+// SeparateArgument and the KeepEmptyValue fields, which would prevent [Value.Strings]
+// from rendering an omitted argument such that parsing again yields the same value.
+type ErrSeparateArgumentWithKeepEmptyValue struct {
+	// Option is the offending option.
+	Option *Option
+}
+
+var _ error = ErrSeparateArgumentWithKeepEmptyValue{}
+
+// Error returns a string representation of this error.
+func (err ErrSeparateArgumentWithKeepEmptyValue) Error() string {
+	return fmt.Sprintf("option cannot use both SeparateArgument and KeepEmptyValue: %s%s", err.Option.Prefix, err.Option.Name)
+}
+
+// ErrInvalidPrefixAlias indicates that an entry of the [*Parser]
+// PrefixAliases field is not valid. That is, the alias is empty or used
+// by some options, or the prefix is not used by any option.
+type ErrInvalidPrefixAlias struct {
+	// Alias is the alias prefix.
+	Alias string
+
+	// Prefix is the prefix the alias stands for.
+	Prefix string
+}
+
+var _ error = ErrInvalidPrefixAlias{}
+
+// Error returns a string representation of this error.
+func (err ErrInvalidPrefixAlias) Error() string {
+	return fmt.Sprintf("invalid prefix alias %q for prefix %q", err.Alias, err.Prefix)
 }
 
 // ErrUnknownOption indicates that an option is unknown.
@@ -101,18 +164,34 @@ func (err ErrUnknownOption) Error() string {
 
 // config contains configuration for parsing options.
 type config struct {
-	// options maps option names to options.
+	// options maps option names, folded when the [*Parser] is
+	// case insensitive, to options.
 	options map[string]*Option
 
 	// parser is the parent parser.
 	parser *Parser
 
+	// permuteDisabled indicates whether permutation is disabled, which we
+	// resolve once, since it may depend on the environment.
+	permuteDisabled bool
+
+	// prefixAliases maps alias prefixes to the corresponding prefixes.
+	prefixAliases map[string]string
+
 	// prefixes maps a prefix to its option type.
 	prefixes map[string]OptionType
 }
 
 // newConfig creates and returns a new [*config] instance.
 func newConfig(px *Parser) (*config, error) {
+	// Make sure that option types are valid, which, in turn, ensures
+	// that we never see unhandled option types when parsing.
+	for _, opt := range px.Options {
+		if !opt.Type.isValid() {
+			return nil, ErrInvalidOptionType{Type: opt.Type, Option: opt}
+		}
+	}
+
 	// Make sure that groupable options have a single-byte name.
 	for _, opt := range px.Options {
 		if len(opt.Name) > 1 && opt.Type.isGroupable() {
@@ -120,6 +199,13 @@ func newConfig(px *Parser) (*config, error) {
 		}
 	}
 
+	// Make sure that we can render options with an omitted argument.
+	for _, opt := range px.Options {
+		if opt.SeparateArgument != nil && opt.KeepEmptyValue {
+			return nil, ErrSeparateArgumentWithKeepEmptyValue{opt}
+		}
+	}
+
 	// Make sure each option name appears exactly once to avoid ambiguity.
 	names := make(map[string][]*Option)
 	for _, opt := range px.Options {
@@ -129,7 +215,8 @@ func newConfig(px *Parser) (*config, error) {
 		case len(opt.Prefix) <= 0:
 			return nil, ErrEmptyOptionPrefix{opt}
 		default:
-			names[opt.Name] = append(names[opt.Name], opt)
+			name := foldOptionName(px, opt.Name)
+			names[name] = append(names[name], opt)
 		}
 	}
 	for name, options := range names {
@@ -138,6 +225,11 @@ func newConfig(px *Parser) (*config, error) {
 		}
 	}
 
+	// Make sure the positional argument slots are consistent.
+	if err := checkPositionalArguments(px.PositionalArguments); err != nil {
+		return nil, err
+	}
+
 	// Collect unique prefixes, ensure they are used consistently across
 	// standalone and groupable options, and configure the scanner for
 	// scanning them. Note that we treat the early options uniformly as
@@ -160,6 +252,20 @@ func newConfig(px *Parser) (*config, error) {
 		}
 	}
 
+	// Make sure the prefix aliases are unambiguous. We ignore the
+	// aliases when there are no options, as explained below.
+	prefixAliases := make(map[string]string)
+	if len(prefixes) > 0 {
+		for alias, prefix := range px.PrefixAliases {
+			_, aliasFound := prefixes[alias]
+			_, prefixFound := prefixes[prefix]
+			if len(alias) <= 0 || aliasFound || !prefixFound {
+				return nil, ErrInvalidPrefixAlias{Alias: alias, Prefix: prefix}
+			}
+			prefixAliases[alias] = prefix
+		}
+	}
+
 	// If no prefixes have been defined, we assume that the user wants
 	// a GNU-style parser, so we add the GNU-style prefixes.
 	//
@@ -173,29 +279,88 @@ func newConfig(px *Parser) (*config, error) {
 	// Create a map between option names and their spec.
 	options := make(map[string]*Option)
 	for _, opt := range px.Options {
-		options[opt.Name] = opt
+		options[foldOptionName(px, opt.Name)] = opt
 	}
 
 	// Build the config instance.
 	cfg := &config{
-		parser:   px,
-		prefixes: prefixes,
-		options:  options,
+		parser:          px,
+		permuteDisabled: px.resolveDisablePermute(),
+		prefixAliases:   prefixAliases,
+		prefixes:        prefixes,
+		options:         options,
 	}
 
 	// Return the config instance.
 	return cfg, nil
 }
 
-// disablePermute returns the value of the [*Parser] DisablePermute flag.
+// disablePermute returns whether permutation is disabled, which depends on
+// the [*Parser] DisablePermute and HonorPosixlyCorrect fields.
 func (cfg *config) disablePermute() bool {
-	return cfg.parser.DisablePermute
+	return cfg.permuteDisabled
+}
+
+// debugLogger returns the [*slog.Logger] to use for emitting the parsing events
+// or nil when the [*Parser] Logger is nil or does not emit debug messages, so
+// that we avoid building the event attributes when nobody is listening.
+func (cfg *config) debugLogger() *slog.Logger {
+	if logger := cfg.parser.Logger; logger != nil && logger.Enabled(context.Background(), slog.LevelDebug) {
+		return logger
+	}
+	return nil
+}
+
+// optionValueDelimiter returns the delimiter between a standalone option name and its value.
+func (cfg *config) optionValueDelimiter() string {
+	if cfg.parser.OptionValueDelimiter == "" {
+		return "="
+	}
+	return cfg.parser.OptionValueDelimiter
+}
+
+// scannerPrefixes returns the sorted prefixes to use for scanning, including the aliases.
+func (cfg *config) scannerPrefixes() []string {
+	prefixes := make([]string, 0, len(cfg.prefixes)+len(cfg.prefixAliases))
+	for prefix := range cfg.prefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	for alias := range cfg.prefixAliases {
+		prefixes = append(prefixes, alias)
+	}
+	slices.Sort(prefixes)
+	return prefixes
+}
+
+// prefixKind returns the kind of options using the given prefix or alias prefix.
+func (cfg *config) prefixKind(prefix string) OptionType {
+	return cfg.prefixes[cfg.resolvePrefix(prefix)]
+}
+
+// resolvePrefix returns the prefix for which the given prefix is an alias
+// or the given prefix itself, when it is not an alias.
+func (cfg *config) resolvePrefix(prefix string) string {
+	if resolved, found := cfg.prefixAliases[prefix]; found {
+		return resolved
+	}
+	return prefix
+}
+
+// foldOptionName returns the name to use for looking up options, which
+// is lowercase when the [*Parser] is case insensitive.
+func foldOptionName(px *Parser, name string) string {
+	if px.CaseInsensitive {
+		return strings.ToLower(name)
+	}
+	return name
 }
 
 // findOption returns an [*Option] associated with the given option name and kind.
+//
+// We honour the [*Parser] PrefixAliases and CaseInsensitive fields.
 func (cfg *config) findOption(tok flagscanner.OptionToken, optname string, kind OptionType) (*Option, error) {
-	option := cfg.options[optname]
-	if option == nil || option.Prefix != tok.Prefix || (option.Type&kind) == 0 {
+	option := cfg.options[foldOptionName(cfg.parser, optname)]
+	if option == nil || option.Prefix != cfg.resolvePrefix(tok.Prefix) || (option.Type&kind) == 0 {
 		err := ErrUnknownOption{Name: optname, Prefix: tok.Prefix, Token: tok}
 		return nil, err
 	}
@@ -9,6 +9,7 @@ package flagparser
 import (
 	"errors"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/bassosimone/flagscanner"
@@ -55,7 +56,7 @@ func TestErrTooLongGroupableOptionName(t *testing.T) {
 	opt := &Option{Name: "longname"}
 	err := ErrTooLongGroupableOptionName{Option: opt}
 
-	expect := "groupable option names should be a single byte, found: &{DefaultValue: Prefix: Name:longname Type:0}"
+	expect := "groupable option names should be a single byte, found: longname (OptionType(0))"
 	assert.Equal(t, expect, err.Error())
 }
 
@@ -63,7 +64,7 @@ func TestErrEmptyOptionName(t *testing.T) {
 	opt := &Option{Name: ""}
 	err := ErrEmptyOptionName{Option: opt}
 
-	expect := "option name cannot be empty: &{DefaultValue: Prefix: Name: Type:0}"
+	expect := "option name cannot be empty:  (OptionType(0))"
 	assert.Equal(t, expect, err.Error())
 }
 
@@ -71,20 +72,98 @@ func TestErrEmptyOptionPrefix(t *testing.T) {
 	opt := &Option{Prefix: ""}
 	err := ErrEmptyOptionPrefix{Option: opt}
 
-	expect := "option prefix cannot be empty: &{DefaultValue: Prefix: Name: Type:0}"
+	expect := "option prefix cannot be empty:  (OptionType(0))"
 	assert.Equal(t, expect, err.Error())
 }
 
+func TestErrSeparateArgumentWithKeepEmptyValue(t *testing.T) {
+	err := ErrSeparateArgumentWithKeepEmptyValue{Option: &Option{Prefix: "--", Name: "color"}}
+	assert.Equal(t, "option cannot use both SeparateArgument and KeepEmptyValue: --color", err.Error())
+}
+
+func TestErrInvalidPrefixAlias(t *testing.T) {
+	err := ErrInvalidPrefixAlias{Alias: "--", Prefix: "-"}
+	assert.Equal(t, `invalid prefix alias "--" for prefix "-"`, err.Error())
+}
+
+func TestErrInvalidOptionType(t *testing.T) {
+	t.Run("without option", func(t *testing.T) {
+		err := ErrInvalidOptionType{Type: optionKindStandalone | optionKindGroupable}
+		assert.Equal(t, "invalid option type: OptionType(96)", err.Error())
+	})
+
+	t.Run("with option", func(t *testing.T) {
+		err := ErrInvalidOptionType{Type: 0, Option: &Option{Prefix: "--", Name: "x"}}
+		expect := "invalid option type: OptionType(0): --x"
+		assert.Equal(t, expect, err.Error())
+	})
+}
+
 func Test_config_disablePermute(t *testing.T) {
-	cases := []bool{true, false}
+	type testcase struct {
+		disablePermute      bool
+		honorPosixlyCorrect bool
+		posixlyCorrect      *string
+		expect              bool
+	}
+
+	empty := ""
+	cases := []testcase{
+		{disablePermute: false, expect: false},
+		{disablePermute: true, expect: true},
+		{disablePermute: false, posixlyCorrect: &empty, expect: false},
+		{disablePermute: false, honorPosixlyCorrect: true, expect: false},
+		{disablePermute: false, honorPosixlyCorrect: true, posixlyCorrect: &empty, expect: true},
+		{disablePermute: true, honorPosixlyCorrect: true, expect: true},
+	}
+
 	for _, tc := range cases {
-		t.Run(fmt.Sprint(tc), func(t *testing.T) {
-			cfg := config{parser: &Parser{DisablePermute: tc}}
-			assert.Equal(t, cfg.parser.DisablePermute, cfg.disablePermute())
+		t.Run(fmt.Sprintf("%+v", tc), func(t *testing.T) {
+			px := &Parser{
+				DisablePermute:      tc.disablePermute,
+				HonorPosixlyCorrect: tc.honorPosixlyCorrect,
+				LookupEnv: func(key string) (string, bool) {
+					assert.Equal(t, "POSIXLY_CORRECT", key)
+					if tc.posixlyCorrect == nil {
+						return "", false
+					}
+					return *tc.posixlyCorrect, true
+				},
+			}
+			cfg, err := newConfig(px)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expect, cfg.disablePermute())
 		})
 	}
 }
 
+func Test_config_disablePermuteLooksUpEnvironmentOnce(t *testing.T) {
+	var count int
+	px := NewParser()
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddOptionWithArgumentNone('v', "verbose")
+	px.HonorPosixlyCorrect = true
+	px.LookupEnv = func(key string) (string, bool) {
+		count++
+		return "", true
+	}
+	values, err := px.Parse([]string{"a", "-v", "b", "c"})
+	assert.NoError(t, err)
+	assert.Len(t, values, 4)
+	assert.Equal(t, 1, count)
+}
+
+func Test_config_scannerPrefixes(t *testing.T) {
+	cfg := config{prefixes: map[string]OptionType{
+		"--": optionKindStandalone,
+		"+":  optionKindStandalone,
+		"-":  optionKindGroupable | optionKindEarly,
+	}}
+	assert.Equal(t, []string{"+", "-", "--"}, cfg.scannerPrefixes())
+}
+
 func Test_config_findOption(t *testing.T) {
 	// Create the option we would like to return to the caller
 	option := Option{
@@ -99,6 +178,7 @@ func Test_config_findOption(t *testing.T) {
 		options: map[string]*Option{
 			"verbose": &option,
 		},
+		parser: &Parser{},
 	}
 
 	// Define the test cases
@@ -187,6 +267,21 @@ func Test_config_findOption(t *testing.T) {
 	}
 }
 
+func Test_newConfigSeparateArgumentWithKeepEmptyValue(t *testing.T) {
+	option := &Option{
+		KeepEmptyValue:   true,
+		SeparateArgument: MatchValues("always", "never"),
+		Prefix:           "--",
+		Name:             "color",
+		Type:             OptionTypeStandaloneArgumentOptional,
+	}
+	cfg, err := newConfig(&Parser{Options: []*Option{option}})
+	assert.Nil(t, cfg)
+	var target ErrSeparateArgumentWithKeepEmptyValue
+	assert.ErrorAs(t, err, &target)
+	assert.Same(t, option, target.Option)
+}
+
 func Test_newConfig(t *testing.T) {
 	// Define the structure of the test cases
 	type testcase struct {
@@ -195,10 +290,32 @@ func Test_newConfig(t *testing.T) {
 		expectErr      error                 // Expected error, if any
 		expectPrefixes map[string]OptionType // Expected prefixes and their types
 		expectOptions  map[string]*Option    // Expected options by name
+		parser         *Parser               // Optional parser template
 	}
 
 	// Define the test cases
 	cases := []testcase{
+		{
+			caseName: "invalid option type",
+			options: []*Option{
+				{
+					Name:   "x",
+					Prefix: "-",
+					Type:   optionKindStandalone | optionKindGroupable | optionArgumentNone,
+				},
+			},
+			expectErr: ErrInvalidOptionType{
+				Type: optionKindStandalone | optionKindGroupable | optionArgumentNone,
+				Option: &Option{
+					Name:   "x",
+					Prefix: "-",
+					Type:   optionKindStandalone | optionKindGroupable | optionArgumentNone,
+				},
+			},
+			expectPrefixes: map[string]OptionType{},
+			expectOptions:  map[string]*Option{},
+		},
+
 		{
 			caseName: "groupable option with multi-byte name",
 			options: []*Option{
@@ -313,6 +430,97 @@ func Test_newConfig(t *testing.T) {
 			expectOptions:  map[string]*Option{},
 		},
 
+		{
+			caseName: "multiple options with same name ignoring case",
+			options: []*Option{
+				{
+					Name:   "out",
+					Prefix: "/",
+					Type:   OptionTypeStandaloneArgumentRequired,
+				},
+				{
+					Name:   "OUT",
+					Prefix: "/",
+					Type:   OptionTypeStandaloneArgumentNone,
+				},
+			},
+			expectErr: ErrMultipleOptionsWithSameName{
+				Name: "out",
+				Options: []*Option{
+					{
+						Name:   "out",
+						Prefix: "/",
+						Type:   OptionTypeStandaloneArgumentRequired,
+					},
+					{
+						Name:   "OUT",
+						Prefix: "/",
+						Type:   OptionTypeStandaloneArgumentNone,
+					},
+				},
+			},
+			expectPrefixes: map[string]OptionType{},
+			expectOptions:  map[string]*Option{},
+			parser:         &Parser{CaseInsensitive: true},
+		},
+
+		{
+			caseName: "prefix alias used by options",
+			options: []*Option{
+				{
+					Name:   "v",
+					Prefix: "-",
+					Type:   OptionTypeGroupableArgumentNone,
+				},
+				{
+					Name:   "verbose",
+					Prefix: "--",
+					Type:   OptionTypeStandaloneArgumentNone,
+				},
+			},
+			expectErr:      ErrInvalidPrefixAlias{Alias: "--", Prefix: "-"},
+			expectPrefixes: map[string]OptionType{},
+			expectOptions:  map[string]*Option{},
+			parser:         &Parser{PrefixAliases: map[string]string{"--": "-"}},
+		},
+
+		{
+			caseName: "prefix alias for an unused prefix",
+			options: []*Option{
+				{
+					Name:   "verbose",
+					Prefix: "-",
+					Type:   OptionTypeStandaloneArgumentNone,
+				},
+			},
+			expectErr:      ErrInvalidPrefixAlias{Alias: "/", Prefix: "+"},
+			expectPrefixes: map[string]OptionType{},
+			expectOptions:  map[string]*Option{},
+			parser:         &Parser{PrefixAliases: map[string]string{"/": "+"}},
+		},
+
+		{
+			caseName: "valid case insensitive configuration with prefix alias",
+			options: []*Option{
+				{
+					Name:   "Verbose",
+					Prefix: "-",
+					Type:   OptionTypeStandaloneArgumentNone,
+				},
+			},
+			expectPrefixes: map[string]OptionType{
+				"-": optionKindStandalone,
+			},
+			expectOptions: map[string]*Option{
+				"verbose": {
+					Name:   "Verbose",
+					Prefix: "-",
+					Type:   OptionTypeStandaloneArgumentNone,
+				},
+			},
+			parser: &Parser{CaseInsensitive: true, PrefixAliases: map[string]string{"--": "-"}},
+		},
+
 		{
 			caseName: "valid configuration",
 			options: []*Option{
@@ -371,7 +579,11 @@ func Test_newConfig(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.caseName, func(t *testing.T) {
 			// Create a parser with the provided options
-			parser := &Parser{Options: tc.options}
+			parser := &Parser{}
+			if tc.parser != nil {
+				parser = tc.parser
+			}
+			parser.Options = tc.options
 
 			// Attempt to create a new config
 			cfg, err := newConfig(parser)
@@ -0,0 +1,276 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bassosimone/flagscanner"
+)
+
+// ErrConfigFile indicates that a configuration file line is not valid.
+type ErrConfigFile struct {
+	// Path is the configuration file path.
+	Path string
+
+	// Line is the 1-based line number.
+	Line int
+
+	// Err is the underlying error (e.g., [ErrUnknownOption]).
+	Err error
+}
+
+var _ error = ErrConfigFile{}
+
+// Error returns a string representation of this error.
+func (err ErrConfigFile) Error() string {
+	return fmt.Sprintf("%s:%d: %s", err.Path, err.Line, err.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (err ErrConfigFile) Unwrap() error {
+	return err.Err
+}
+
+// ErrConfigSyntax indicates that a configuration file line has invalid syntax.
+type ErrConfigSyntax struct {
+	// Reason explains why the syntax is invalid.
+	Reason string
+}
+
+var _ error = ErrConfigSyntax{}
+
+// Error returns a string representation of this error.
+func (err ErrConfigSyntax) Error() string {
+	return fmt.Sprintf("invalid syntax: %s", err.Reason)
+}
+
+// ConfigFileToken is the [flagscanner.Token] of a [ValueOption]
+// that we read from a configuration file.
+type ConfigFileToken struct {
+	// Path is the configuration file path.
+	Path string
+
+	// Line is the 1-based line number.
+	Line int
+
+	// Text is the line text without leading and trailing spaces.
+	Text string
+}
+
+var _ flagscanner.Token = ConfigFileToken{}
+
+// Index implements [flagscanner.Token] by returning the line number.
+func (tk ConfigFileToken) Index() int {
+	return tk.Line
+}
+
+// String implements [flagscanner.Token] by returning the line text.
+func (tk ConfigFileToken) String() string {
+	return tk.Text
+}
+
+// ParseConfigFile opens the configuration file at the given path and
+// parses it using [*Parser.ParseConfig].
+func (px *Parser) ParseConfigFile(path string) ([]Value, error) {
+	filep, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer filep.Close()
+	return px.ParseConfig(filep, path)
+}
+
+// ParseConfig parses an INI-like configuration file whose keys are long option names
+// (e.g., `output` for `--output`), like git config or TOML files without tables,
+// and returns a [ValueOption] for each key, in file order.
+//
+// Keys only match standalone options, therefore we do not accept the names of
+// groupable options (e.g., `o` for `-o`), which should have a standalone alias
+// to be configurable, nor the names of early options (e.g., `help`), which are
+// actions rather than settings.
+//
+// We skip empty lines and comments starting with `#` or `;`. Other lines
+// must either contain a bare key (e.g., `verbose`) or a key and a value
+// separated by `=` (e.g., `output = index.html`). A value is either double
+// quoted, using `\"`, `\\`, `\n`, `\r`, and `\t` as escapes, or single quoted,
+// without escapes, or unquoted, in which case the value ends before a `#`
+// or `;` preceded by whitespace, which starts a comment.
+//
+// We map the keys according to the [OptionType] of the option, as follows:
+//
+//  1. options taking no argument accept a bare key, `true`, or `false`, in
+//     which case we skip the option
+//
+//  2. options requiring an argument require a value
+//
+//  3. options with an optional argument use the DefaultValue for
+//     a bare key, and the value otherwise
+//
+// The path is only used for errors and for the [ConfigFileToken] of the values.
+// Use the returned values as the [*Resolver] File field to merge them with the
+// environment variables and the command line.
+//
+// We return [ErrConfigFile] wrapping the specific error (e.g., [ErrUnknownOption]
+// for unknown keys, [ErrConfigSyntax], or [ErrOptionValueNotAllowed]) when a line
+// is not valid, and the same errors that [*Parser.Parse] returns when the
+// [*Parser] is not valid.
+func (px *Parser) ParseConfig(r io.Reader, path string) ([]Value, error) {
+	cfg, err := newConfig(px)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		scanner = bufio.NewScanner(r)
+		values  []Value
+	)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		tok := ConfigFileToken{Path: path, Line: lineno, Text: strings.TrimSpace(scanner.Text())}
+		value, found, err := parseConfigLine(cfg, tok)
+		if err != nil {
+			return nil, ErrConfigFile{Path: path, Line: lineno, Err: err}
+		}
+		if found {
+			values = append(values, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseConfigLine parses a configuration file line and returns the corresponding
+// value, if any. The found return value is false for empty and comment lines.
+func parseConfigLine(cfg *config, tok ConfigFileToken) (ValueOption, bool, error) {
+	// Skip empty lines and comments
+	if tok.Text == "" || tok.Text[0] == '#' || tok.Text[0] == ';' {
+		return ValueOption{}, false, nil
+	}
+	if tok.Text[0] == '[' {
+		return ValueOption{}, false, ErrConfigSyntax{Reason: "sections are not supported"}
+	}
+
+	// Split the key from the value
+	key, rawValue, hasValue := strings.Cut(tok.Text, "=")
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return ValueOption{}, false, ErrConfigSyntax{Reason: fmt.Sprintf("invalid key: %q", key)}
+	}
+
+	// Find the corresponding standalone option
+	option := cfg.options[foldOptionName(cfg.parser, key)]
+	if option == nil || !option.Type.isStandalone() {
+		return ValueOption{}, false, ErrUnknownOption{Name: key, Token: tok}
+	}
+
+	// Bare keys either enable an option or select its default value
+	value := ValueOption{Option: option, Tok: tok, Delimiter: cfg.parser.OptionValueDelimiter}
+	if !hasValue {
+		switch option.Type.argumentKind() {
+		case optionArgumentRequired:
+			return ValueOption{}, false, ErrOptionRequiresArgument{Option: option, Token: tok}
+		case optionArgumentOptional:
+			value.Value = option.DefaultValue
+		}
+		return value, true, nil
+	}
+
+	// Otherwise, parse and validate the value
+	parsed, err := parseConfigValue(strings.TrimSpace(rawValue))
+	if err != nil {
+		return ValueOption{}, false, err
+	}
+	if option.Type.argumentKind() == optionArgumentNone {
+		set, err := parseNoArgumentValue(option, tok, parsed)
+		if err != nil || !set {
+			return ValueOption{}, false, err
+		}
+		return value, true, nil
+	}
+	value.Value, value.Explicit = parsed, true
+	if err := validateOptionValue(value); err != nil {
+		return ValueOption{}, false, err
+	}
+	return value, true, nil
+}
+
+// parseNoArgumentValue returns whether the `true` or `false` value
+// of an option taking no argument sets the option.
+func parseNoArgumentValue(option *Option, tok flagscanner.Token, value string) (bool, error) {
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, ErrOptionRequiresNoArgument{Option: option, Token: tok}
+	}
+}
+
+// parseConfigValue parses a double quoted, single quoted, or unquoted value.
+func parseConfigValue(input string) (string, error) {
+	switch {
+	case strings.HasPrefix(input, `"`):
+		var sb strings.Builder
+		for idx := 1; idx < len(input); idx++ {
+			switch ch := input[idx]; ch {
+			case '"':
+				return sb.String(), checkConfigValueTrailer(input[idx+1:])
+			case '\\':
+				idx++
+				if idx >= len(input) {
+					break
+				}
+				escaped, found := configValueEscapes[input[idx]]
+				if !found {
+					return "", ErrConfigSyntax{Reason: fmt.Sprintf("invalid escape: \\%c", input[idx])}
+				}
+				sb.WriteByte(escaped)
+			default:
+				sb.WriteByte(ch)
+			}
+		}
+		return "", ErrConfigSyntax{Reason: "unterminated double quoted value"}
+
+	case strings.HasPrefix(input, `'`):
+		value, trailer, found := strings.Cut(input[1:], `'`)
+		if !found {
+			return "", ErrConfigSyntax{Reason: "unterminated single quoted value"}
+		}
+		return value, checkConfigValueTrailer(trailer)
+
+	default:
+		for idx := 1; idx < len(input); idx++ {
+			if (input[idx] == '#' || input[idx] == ';') && (input[idx-1] == ' ' || input[idx-1] == '\t') {
+				return strings.TrimSpace(input[:idx]), nil
+			}
+		}
+		return input, nil
+	}
+}
+
+// configValueEscapes maps the escapes of double quoted values to the corresponding bytes.
+var configValueEscapes = map[byte]byte{
+	'"':  '"',
+	'\\': '\\',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+}
+
+// checkConfigValueTrailer ensures that only spaces or a comment follow a quoted value.
+func checkConfigValueTrailer(trailer string) error {
+	trailer = strings.TrimSpace(trailer)
+	if trailer != "" && trailer[0] != '#' && trailer[0] != ';' {
+		return ErrConfigSyntax{Reason: fmt.Sprintf("unexpected text after quoted value: %q", trailer)}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrConfigFile(t *testing.T) {
+	err := ErrConfigFile{Path: "curlrc", Line: 4, Err: ErrUnknownOption{Name: "verbos"}}
+	assert.Equal(t, "curlrc:4: unknown option: verbos", err.Error())
+	assert.True(t, errors.Is(err, ErrUnknownOption{Name: "verbos"}))
+}
+
+func TestErrConfigSyntax(t *testing.T) {
+	err := ErrConfigSyntax{Reason: "sections are not supported"}
+	assert.Equal(t, "invalid syntax: sections are not supported", err.Error())
+}
+
+func TestConfigFileToken(t *testing.T) {
+	tok := ConfigFileToken{Path: "curlrc", Line: 4, Text: "verbose"}
+	assert.Equal(t, 4, tok.Index())
+	assert.Equal(t, "verbose", tok.String())
+}
+
+func TestParserParseConfig(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		input := strings.Join([]string{
+			"# curl-like configuration",
+			"",
+			"verbose",
+			"silent = false",
+			"  output = index.html # comment",
+			`header = "a: \"b\"\t;c" ; comment`,
+			`header = 'x: \y'`,
+			"http",
+			"http = 2",
+		}, "\n")
+		values, err := newTestCurlParser().ParseConfig(strings.NewReader(input), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var got [][]string
+		for _, value := range values {
+			got = append(got, value.Strings())
+		}
+		expect := [][]string{
+			{"--verbose"},
+			{"--output", "index.html"},
+			{"--header", "a: \"b\"\t;c"},
+			{"--header", `x: \y`},
+			{"--http"},
+			{"--http=2"},
+		}
+		assert.Equal(t, expect, got)
+		assert.Equal(t, ConfigFileToken{Path: "curlrc", Line: 5, Text: "output = index.html # comment"}, values[1].Token())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := []struct {
+			input  string
+			expect string
+		}{
+			{"verbose\nverbos", `curlrc:2: unknown option: verbos`},
+			{"v = true", `curlrc:1: unknown option: v`},
+			{"[section]", `curlrc:1: invalid syntax: sections are not supported`},
+			{"= x", `curlrc:1: invalid syntax: invalid key: ""`},
+			{"out put = x", `curlrc:1: invalid syntax: invalid key: "out put"`},
+			{`output = "x`, `curlrc:1: invalid syntax: unterminated double quoted value`},
+			{`output = "x\`, `curlrc:1: invalid syntax: unterminated double quoted value`},
+			{`output = "\x"`, `curlrc:1: invalid syntax: invalid escape: \x`},
+			{`output = 'x`, `curlrc:1: invalid syntax: unterminated single quoted value`},
+			{`output = 'x' y`, `curlrc:1: invalid syntax: unexpected text after quoted value: "y"`},
+			{"output", `curlrc:1: option requires an argument: --output`},
+			{"verbose = 1", `curlrc:1: option requires no argument: --verbose`},
+			{"http = 3", `curlrc:1: invalid value for option --http: "3" (allowed values: 1.0, 1.1, 2)`},
+		}
+		for _, tc := range cases {
+			t.Run(tc.input, func(t *testing.T) {
+				values, err := newTestCurlParser().ParseConfig(strings.NewReader(tc.input), "curlrc")
+				assert.Nil(t, values)
+				var errvalue ErrConfigFile
+				if assert.True(t, errors.As(err, &errvalue)) {
+					assert.Equal(t, tc.expect, err.Error())
+				}
+			})
+		}
+	})
+
+	t.Run("early options", func(t *testing.T) {
+		px := newTestCurlParser()
+		px.AddEarlyOption('h', "help")
+		values, err := px.ParseConfig(strings.NewReader("help"), "curlrc")
+		assert.Nil(t, values)
+		assert.Equal(t, "curlrc:1: unknown option: help", err.Error())
+	})
+
+	t.Run("invalid parser", func(t *testing.T) {
+		px := &Parser{Options: []*Option{{Prefix: "-", Name: "xy", Type: OptionTypeGroupableArgumentNone}}}
+		values, err := px.ParseConfig(strings.NewReader(""), "curlrc")
+		assert.Nil(t, values)
+		var errvalue ErrTooLongGroupableOptionName
+		assert.True(t, errors.As(err, &errvalue))
+	})
+}
+
+func TestParserParseConfigFile(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "curlrc")
+		if err := os.WriteFile(path, []byte("silent\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		values, err := newTestCurlParser().ParseConfigFile(path)
+		if assert.NoError(t, err) && assert.Len(t, values, 1) {
+			assert.Equal(t, []string{"--silent"}, values[0].Strings())
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		values, err := newTestCurlParser().ParseConfigFile(filepath.Join(t.TempDir(), "curlrc"))
+		assert.Nil(t, values)
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+}
@@ -0,0 +1,271 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bassosimone/flagscanner"
+)
+
+// ConfigArgs contains the command line arguments read from a configuration
+// file along with the line where each argument comes from.
+//
+// Construct using [*Parser.ReadCurlrc] or [*Parser.ReadCurlrcFile].
+type ConfigArgs struct {
+	// Path is the configuration file path.
+	Path string
+
+	// Args contains the command line arguments.
+	Args []string
+
+	// Lines contains the 1-based line number of each argument.
+	Lines []int
+}
+
+// ReadCurlrcFile opens the curlrc-style configuration file at
+// the given path and reads it using [*Parser.ReadCurlrc].
+func (px *Parser) ReadCurlrcFile(path string) (*ConfigArgs, error) {
+	filep, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer filep.Close()
+	return px.ReadCurlrc(filep, path)
+}
+
+// ReadCurlrc reads a configuration file using the curl `-K` syntax, where each
+// line contains an option and its parameter, if any, and returns the equivalent
+// command line arguments, which you can parse using [*ConfigArgs.Parse].
+//
+// We skip empty lines and comments starting with `#`. Other lines contain
+// an option name, with or without a prefix (e.g., `-v`, `--output`, or
+// `output`), optionally followed by a parameter, separated by spaces, `=`,
+// or `:` (e.g., `output = "index.html"`). A parameter is either double quoted,
+// using `\"`, `\\`, `\n`, `\r`, `\t`, and `\v` as escapes, or unquoted, in
+// which case it ends at the first space. Like curl, which adds `--` to the
+// names without leading dashes, we add the long option prefix to the names
+// without a [*Parser] prefix, that is, the prefix of the standalone or early
+// option with such a name or, otherwise, of the standalone options (e.g.,
+// `--` for [NewParser] and `/` for [NewWindowsParser]).
+//
+// We map each line to arguments as follows:
+//
+//  1. a line without a parameter becomes a single argument (e.g., `--verbose`)
+//
+//  2. a line with a parameter and a name using a standalone options prefix
+//     becomes a single argument using the [*Parser] OptionValueDelimiter, `=`
+//     by default, as the delimiter (e.g., `--output=index.html`), which also
+//     works for options with an optional argument
+//
+//  3. a line with a parameter and the name of a groupable option with an
+//     optional argument becomes a single argument (e.g., `-cval`)
+//
+//  4. any other line with a parameter becomes two arguments (e.g., `-o`, `index.html`)
+//
+// The path is only used for errors and for the [*ConfigArgs] Path field.
+//
+// We return [ErrConfigFile] wrapping [ErrConfigSyntax] when a line is not valid,
+// and the same errors that [*Parser.Parse] returns when the [*Parser] is not valid.
+func (px *Parser) ReadCurlrc(r io.Reader, path string) (*ConfigArgs, error) {
+	cfg, err := newConfig(px)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ca      = &ConfigArgs{Path: path}
+		scanner = bufio.NewScanner(r)
+	)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		args, err := parseCurlrcLine(cfg, strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			return nil, ErrConfigFile{Path: path, Line: lineno, Err: err}
+		}
+		for _, arg := range args {
+			ca.Args = append(ca.Args, arg)
+			ca.Lines = append(ca.Lines, lineno)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// Parse parses the arguments using the given [*Parser] and returns the
+// same values and errors that [*Parser.Parse] returns, except that we wrap
+// the errors referring to a token using [ErrConfigFile] to report the line
+// (e.g., `curlrc:4: unknown option: --verbos`).
+func (ca *ConfigArgs) Parse(px *Parser) ([]Value, error) {
+	values, err := px.Parse(ca.Args)
+	if err != nil {
+		if tok := errorToken(err); tok != nil && tok.Index() >= 0 && tok.Index() < len(ca.Lines) {
+			err = ErrConfigFile{Path: ca.Path, Line: ca.Lines[tok.Index()], Err: err}
+		}
+		return nil, err
+	}
+	return values, nil
+}
+
+// errorToken returns the token referred to by a [*Parser.Parse] error, if any.
+func errorToken(err error) flagscanner.Token {
+	var (
+		unknownOption   ErrUnknownOption
+		requiresNoArg   ErrOptionRequiresNoArgument
+		requiresArg     ErrOptionRequiresArgument
+		notAllowed      ErrOptionValueNotAllowed
+		invalidValue    ErrOptionValueInvalid
+		invalidArgument ErrPositionalArgumentInvalid
+	)
+	switch {
+	case errors.As(err, &unknownOption):
+		return unknownOption.Token
+	case errors.As(err, &requiresNoArg):
+		return requiresNoArg.Token
+	case errors.As(err, &requiresArg):
+		return requiresArg.Token
+	case errors.As(err, &notAllowed):
+		return notAllowed.Token
+	case errors.As(err, &invalidValue):
+		return invalidValue.Token
+	case errors.As(err, &invalidArgument):
+		return invalidArgument.Token
+	default:
+		return nil
+	}
+}
+
+// parseCurlrcLine parses a curlrc line and returns the corresponding arguments.
+func parseCurlrcLine(cfg *config, line string) ([]string, error) {
+	// Skip empty lines and comments
+	if line == "" || line[0] == '#' {
+		return nil, nil
+	}
+
+	// Read the option name, adding the long option prefix if needed
+	end := strings.IndexAny(line, " \t=:")
+	if end < 0 {
+		end = len(line)
+	}
+	name, rest := line[:end], line[end:]
+	prefix, bare := splitCurlrcName(cfg, name)
+	if prefix == "" {
+		prefix = curlrcLongOptionPrefix(cfg, bare)
+		if prefix == "" {
+			return nil, ErrConfigSyntax{Reason: fmt.Sprintf("no long option prefix for %q", name)}
+		}
+		name = prefix + bare
+	}
+
+	// Skip the separator between the name and the parameter
+	rest = strings.TrimLeft(rest, " \t")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t")
+	}
+	if rest == "" {
+		return []string{name}, nil
+	}
+
+	// Read the parameter
+	param, err := parseCurlrcParam(rest)
+	if err != nil {
+		return nil, err
+	}
+	option := cfg.options[foldOptionName(cfg.parser, bare)]
+	switch {
+	case option != nil && option.Prefix == cfg.resolvePrefix(prefix) && option.Type == OptionTypeGroupableArgumentOptional:
+		return []string{name + param}, nil
+	case cfg.prefixKind(prefix).isStandalone():
+		return []string{name + cfg.optionValueDelimiter() + param}, nil
+	default:
+		return []string{name, param}, nil
+	}
+}
+
+// splitCurlrcName splits a curlrc option name into the longest prefix, including
+// the alias prefixes, it starts with, if any, and the name without the prefix.
+func splitCurlrcName(cfg *config, name string) (string, string) {
+	var prefix string
+	for _, candidate := range cfg.scannerPrefixes() {
+		if strings.HasPrefix(name, candidate) && len(candidate) > len(prefix) {
+			prefix = candidate
+		}
+	}
+	return prefix, name[len(prefix):]
+}
+
+// curlrcLongOptionPrefix returns the prefix to add to a curlrc option name
+// without a prefix, which is the prefix of the non-groupable option with such
+// a name, if any, or the first standalone options prefix, if any.
+func curlrcLongOptionPrefix(cfg *config, name string) string {
+	if option := cfg.options[foldOptionName(cfg.parser, name)]; option != nil && !option.Type.isGroupable() {
+		return option.Prefix
+	}
+	var prefixes []string
+	for prefix, kind := range cfg.prefixes {
+		if kind.isStandalone() {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	slices.Sort(prefixes)
+	if len(prefixes) <= 0 {
+		return ""
+	}
+	return prefixes[0]
+}
+
+// parseCurlrcParam parses a double quoted or unquoted curlrc parameter.
+func parseCurlrcParam(input string) (string, error) {
+	if !strings.HasPrefix(input, `"`) {
+		end := strings.IndexAny(input, " \t")
+		if end < 0 {
+			return input, nil
+		}
+		return input[:end], checkCurlrcParamTrailer(input[end:])
+	}
+	var sb strings.Builder
+	for idx := 1; idx < len(input); idx++ {
+		switch ch := input[idx]; ch {
+		case '"':
+			return sb.String(), checkCurlrcParamTrailer(input[idx+1:])
+		case '\\':
+			idx++
+			if idx >= len(input) {
+				break
+			}
+			escaped, found := curlrcParamEscapes[input[idx]]
+			if !found {
+				escaped = input[idx] // like curl, keep unknown escaped bytes
+			}
+			sb.WriteByte(escaped)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return "", ErrConfigSyntax{Reason: "unterminated double quoted parameter"}
+}
+
+// curlrcParamEscapes maps the escapes of double quoted parameters to the corresponding bytes.
+var curlrcParamEscapes = map[byte]byte{
+	'n': '\n',
+	'r': '\r',
+	't': '\t',
+	'v': '\v',
+}
+
+// checkCurlrcParamTrailer ensures that only spaces follow a parameter.
+func checkCurlrcParamTrailer(trailer string) error {
+	if trailer = strings.TrimSpace(trailer); trailer != "" {
+		return ErrConfigSyntax{Reason: fmt.Sprintf("unexpected text after parameter: %q", trailer)}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_ReadCurlrc(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		input := strings.Join([]string{
+			"# curl configuration",
+			"",
+			"-v",
+			"--silent",
+			"  output = \"index file.html\"",
+			"header: \"a: \\\"b\\\"\\tc\\\\\"",
+			"-H x:y",
+			"--http=2",
+			"http",
+			`url "https://example.com/"`,
+		}, "\n")
+		ca, err := NewParser().ReadCurlrc(strings.NewReader(input), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		expect := &ConfigArgs{
+			Path: "curlrc",
+			Args: []string{
+				"-v",
+				"--silent",
+				"--output=index file.html",
+				"--header=a: \"b\"\tc\\",
+				"-H", "x:y",
+				"--http=2",
+				"--http",
+				"--url=https://example.com/",
+			},
+			Lines: []int{3, 4, 5, 6, 7, 7, 8, 9, 10},
+		}
+		assert.Equal(t, expect, ca)
+	})
+
+	t.Run("custom option value delimiter", func(t *testing.T) {
+		px := newTestCurlParser()
+		px.OptionValueDelimiter = ":"
+		ca, err := px.ReadCurlrc(strings.NewReader("output = x.txt\nhttp: 2\n"), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"--output:x.txt", "--http:2"}, ca.Args)
+		fromFile, err := ca.Parse(px)
+		if !assert.NoError(t, err) {
+			return
+		}
+		fromArgv, err := px.Parse([]string{"--output", "x.txt", "--http:2"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, Normalize(fromArgv, NormalizeStyleLong), Normalize(fromFile, NormalizeStyleLong))
+	})
+
+	t.Run("windows parser", func(t *testing.T) {
+		px := NewWindowsParser()
+		px.AddOption(
+			&Option{Prefix: "/", Name: "out", Type: OptionTypeStandaloneArgumentRequired},
+			&Option{Prefix: "/", Name: "verbose", Type: OptionTypeStandaloneArgumentNone},
+		)
+		ca, err := px.ReadCurlrc(strings.NewReader("/out y\nOUT x\nverbose\n"), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"/out:y", "/OUT:x", "/verbose"}, ca.Args)
+		fromFile, err := ca.Parse(px)
+		if !assert.NoError(t, err) {
+			return
+		}
+		fromArgv, err := px.Parse([]string{"/out:y", "/OUT:x", "/verbose"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, fromArgv, fromFile)
+	})
+
+	t.Run("groupable option with an optional argument", func(t *testing.T) {
+		px, err := NewGetoptParser("vc::o:", nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		ca, err := px.ReadCurlrc(strings.NewReader("-v\n-c val\n-c\n-o out\n"), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"-v", "-cval", "-c", "-o", "out"}, ca.Args)
+		fromFile, err := ca.Parse(px)
+		if !assert.NoError(t, err) {
+			return
+		}
+		fromArgv, err := px.Parse([]string{"-v", "-cval", "-c", "-o", "out"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, fromArgv, fromFile)
+	})
+
+	t.Run("no long option prefix", func(t *testing.T) {
+		px, err := NewGetoptParser("vc::o:", nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		ca, err := px.ReadCurlrc(strings.NewReader("verbose\n"), "curlrc")
+		assert.Nil(t, ca)
+		assert.EqualError(t, err, `curlrc:1: invalid syntax: no long option prefix for "verbose"`)
+	})
+
+	t.Run("invalid parser", func(t *testing.T) {
+		px := &Parser{Options: []*Option{{Prefix: "-", Name: "xy", Type: OptionTypeGroupableArgumentNone}}}
+		ca, err := px.ReadCurlrc(strings.NewReader("verbose\n"), "curlrc")
+		assert.Nil(t, ca)
+		var errvalue ErrTooLongGroupableOptionName
+		assert.True(t, errors.As(err, &errvalue))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := []struct {
+			input  string
+			expect string
+		}{
+			{"-v\noutput = \"x", `curlrc:2: invalid syntax: unterminated double quoted parameter`},
+			{`output = "x\`, `curlrc:1: invalid syntax: unterminated double quoted parameter`},
+			{`output = "x" y`, `curlrc:1: invalid syntax: unexpected text after parameter: "y"`},
+			{"output = x y", `curlrc:1: invalid syntax: unexpected text after parameter: "y"`},
+		}
+		for _, tc := range cases {
+			t.Run(tc.input, func(t *testing.T) {
+				ca, err := NewParser().ReadCurlrc(strings.NewReader(tc.input), "curlrc")
+				assert.Nil(t, ca)
+				var errvalue ErrConfigFile
+				if assert.True(t, errors.As(err, &errvalue)) {
+					assert.Equal(t, tc.expect, err.Error())
+				}
+			})
+		}
+	})
+}
+
+func TestParser_ReadCurlrcFile(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "curlrc")
+		if err := os.WriteFile(path, []byte("silent\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		ca, err := NewParser().ReadCurlrcFile(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"--silent"}, ca.Args)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		ca, err := NewParser().ReadCurlrcFile(filepath.Join(t.TempDir(), "curlrc"))
+		assert.Nil(t, ca)
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+}
+
+func TestConfigArgsParse(t *testing.T) {
+	t.Run("same values as the command line", func(t *testing.T) {
+		px := newTestCurlParser()
+		ca, err := px.ReadCurlrc(strings.NewReader("verbose\n-o x.txt\nhttp = 2\n"), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		fromFile, err := ca.Parse(px)
+		if !assert.NoError(t, err) {
+			return
+		}
+		fromArgv, err := px.Parse([]string{"--verbose", "-o", "x.txt", "--http=2"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, fromArgv, fromFile)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := []struct {
+			input  string
+			expect string
+		}{
+			{"verbose\n\nverbos", `curlrc:3: unknown option: --verbos`},
+			{"verbose = 1", `curlrc:1: option requires no argument: --verbose`},
+			{"-v\n-o", `curlrc:2: option requires an argument: -o`},
+			{"http: 3", `curlrc:1: invalid value for option --http: "3" (allowed values: 1.0, 1.1, 2)`},
+		}
+		for _, tc := range cases {
+			t.Run(tc.input, func(t *testing.T) {
+				px := newTestCurlParser()
+				ca, err := px.ReadCurlrc(strings.NewReader(tc.input), "curlrc")
+				if !assert.NoError(t, err) {
+					return
+				}
+				values, err := ca.Parse(px)
+				assert.Nil(t, values)
+				var errvalue ErrConfigFile
+				if assert.True(t, errors.As(err, &errvalue)) {
+					assert.Equal(t, tc.expect, err.Error())
+				}
+			})
+		}
+	})
+
+	t.Run("errors without a token", func(t *testing.T) {
+		ca := &ConfigArgs{Path: "curlrc", Args: []string{"x"}, Lines: []int{1}}
+		px := newTestCurlParser()
+		px.SetMinMaxPositionalArguments(0, 0)
+		values, err := ca.Parse(px)
+		assert.Nil(t, values)
+		assert.Equal(t, ErrTooManyPositionalArguments{Max: 0, Have: 1}, err)
+	})
+}
@@ -0,0 +1,123 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bassosimone/flagscanner"
+)
+
+// Description is a read-only description of a validated [*Parser] that
+// is useful to build help, completion, documentation, or linters.
+//
+// The description reflects the [*Parser] at the time of [*Parser.Describe],
+// so it does not change when you later modify the [*Parser] fields.
+//
+// Construct using [*Parser.Describe].
+type Description struct {
+	// Prefixes contains the prefixes used by the parser, sorted by prefix.
+	//
+	// When the parser has no options, this field contains the GNU-style
+	// prefixes that the parser falls back to (i.e., `-` and `--`).
+	Prefixes []PrefixDescription
+
+	// Options contains the options in the order in which they were declared.
+	//
+	// You MUST NOT modify the options.
+	Options []*Option
+
+	// PrefixAliases maps the alias prefixes to the prefixes in Prefixes. See
+	// the [*Parser] PrefixAliases field for details.
+	PrefixAliases map[string]string
+
+	// ScannerPrefixes contains the sorted prefixes that the parser uses
+	// to recognize options in the command line, including the aliases.
+	ScannerPrefixes []string
+
+	// cfg is the validated configuration of a copy of the [*Parser].
+	cfg *config
+}
+
+// PrefixDescription describes a prefix used by a [*Parser].
+type PrefixDescription struct {
+	// Prefix is the prefix (e.g., `--`).
+	Prefix string
+
+	// Early indicates that early options use this prefix.
+	Early bool
+
+	// Groupable indicates that groupable options use this prefix.
+	Groupable bool
+
+	// Standalone indicates that standalone options use this prefix.
+	Standalone bool
+}
+
+// EarlyOnly returns true when only early options use this prefix.
+func (pd PrefixDescription) EarlyOnly() bool {
+	return pd.Early && !pd.Groupable && !pd.Standalone
+}
+
+// Describe validates the [*Parser] and returns its [*Description].
+//
+// This method does not mutate [*Parser] and is safe to call concurrently.
+//
+// This method returns the same errors that [*Parser.Parse] returns for
+// an invalid configuration (e.g., [ErrAmbiguousPrefix]).
+func (px *Parser) Describe() (*Description, error) {
+	// Validate a copy, such that the configuration, which refers
+	// to its parser, does not change along with the [*Parser]
+	snapshot := *px
+	snapshot.Options = slices.Clone(px.Options)
+	snapshot.PrefixAliases = maps.Clone(px.PrefixAliases)
+	cfg, err := newConfig(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+	desc := &Description{
+		Options:         slices.Clone(snapshot.Options),
+		PrefixAliases:   maps.Clone(cfg.prefixAliases),
+		ScannerPrefixes: cfg.scannerPrefixes(),
+		cfg:             cfg,
+	}
+	for _, prefix := range slices.Sorted(maps.Keys(cfg.prefixes)) {
+		kind := cfg.prefixes[prefix]
+		desc.Prefixes = append(desc.Prefixes, PrefixDescription{
+			Prefix:     prefix,
+			Early:      kind.isEarly(),
+			Groupable:  kind.isGroupable(),
+			Standalone: kind.isStandalone(),
+		})
+	}
+	return desc, nil
+}
+
+// LookupOption returns the [*Option] with the given prefix and name, if any.
+//
+// Like [*Parser.Parse], we honour the [*Parser] PrefixAliases and
+// CaseInsensitive fields (e.g., we find `-verbose` when looking up
+// `--VERBOSE` with a case insensitive Go-style parser).
+func (desc *Description) LookupOption(prefix, name string) (*Option, bool) {
+	tok := flagscanner.OptionToken{Prefix: prefix, Name: name}
+	option, err := desc.cfg.findOption(tok, name, optionKindEarly|optionKindGroupable|optionKindStandalone)
+	if err != nil {
+		return nil, false
+	}
+	return option, true
+}
+
+// LookupPrefix returns the [PrefixDescription] of the given prefix, if any.
+func (desc *Description) LookupPrefix(prefix string) (PrefixDescription, bool) {
+	idx, found := slices.BinarySearchFunc(desc.Prefixes, prefix, func(pd PrefixDescription, prefix string) int {
+		return strings.Compare(pd.Prefix, prefix)
+	})
+	if !found {
+		return PrefixDescription{}, false
+	}
+	return desc.Prefixes[idx], true
+}
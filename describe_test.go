@@ -0,0 +1,108 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParserDescribe(t *testing.T) {
+	t.Run("dig-like parser", func(t *testing.T) {
+		px := &Parser{}
+		px.AddEarlyOption('h', "")
+		px.AddOption(&Option{Prefix: "-", Name: "p", Type: OptionTypeGroupableArgumentRequired})
+		px.AddOption(&Option{Prefix: "+", Name: "short", Type: OptionTypeStandaloneArgumentNone})
+		px.AddOption(&Option{Prefix: "/", Name: "?", Type: OptionTypeEarlyArgumentNone})
+
+		desc, err := px.Describe()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []PrefixDescription{
+			{Prefix: "+", Standalone: true},
+			{Prefix: "-", Early: true, Groupable: true},
+			{Prefix: "/", Early: true},
+		}, desc.Prefixes)
+		assert.Equal(t, []string{"+", "-", "/"}, desc.ScannerPrefixes)
+		assert.Equal(t, px.Options, desc.Options)
+
+		early, found := desc.LookupPrefix("/")
+		assert.True(t, found)
+		assert.True(t, early.EarlyOnly())
+		groupable, found := desc.LookupPrefix("-")
+		assert.True(t, found)
+		assert.False(t, groupable.EarlyOnly())
+		_, found = desc.LookupPrefix("--")
+		assert.False(t, found)
+
+		option, found := desc.LookupOption("+", "short")
+		assert.True(t, found)
+		assert.Same(t, px.Options[2], option)
+		_, found = desc.LookupOption("-", "short")
+		assert.False(t, found)
+		_, found = desc.LookupOption("+", "long")
+		assert.False(t, found)
+	})
+
+	t.Run("GNU fallback without options", func(t *testing.T) {
+		desc, err := NewParser().Describe()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []PrefixDescription{
+			{Prefix: "-", Groupable: true},
+			{Prefix: "--", Standalone: true},
+		}, desc.Prefixes)
+		assert.Equal(t, []string{"-", "--"}, desc.ScannerPrefixes)
+		assert.Empty(t, desc.Options)
+	})
+
+	t.Run("Go-style case insensitive parser", func(t *testing.T) {
+		px := NewGoParser()
+		px.CaseInsensitive = true
+		px.AddOption(&Option{Prefix: "-", Name: "verbose", Type: OptionTypeStandaloneArgumentNone})
+
+		desc, err := px.Describe()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []PrefixDescription{{Prefix: "-", Standalone: true}}, desc.Prefixes)
+		assert.Equal(t, map[string]string{"--": "-"}, desc.PrefixAliases)
+		assert.Equal(t, []string{"-", "--"}, desc.ScannerPrefixes)
+
+		option, found := desc.LookupOption("--", "VERBOSE")
+		assert.True(t, found)
+		assert.Same(t, px.Options[0], option)
+	})
+
+	t.Run("we are not affected by later parser changes", func(t *testing.T) {
+		px := NewParser()
+		px.AddOptionWithArgumentNone('v', "verbose")
+		desc, err := px.Describe()
+		if !assert.NoError(t, err) {
+			return
+		}
+		px.CaseInsensitive = true
+		px.PrefixAliases = map[string]string{"/": "--"}
+		px.Options = nil
+		_, found := desc.LookupOption("--", "VERBOSE")
+		assert.False(t, found)
+		option, found := desc.LookupOption("--", "verbose")
+		assert.True(t, found)
+		assert.Equal(t, "verbose", option.Name)
+		assert.Len(t, desc.Options, 2)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		px := &Parser{}
+		px.AddOption(&Option{Prefix: "-", Name: "v", Type: OptionTypeGroupableArgumentNone})
+		px.AddOption(&Option{Prefix: "-", Name: "verbose", Type: OptionTypeStandaloneArgumentNone})
+		desc, err := px.Describe()
+		assert.Nil(t, desc)
+		assert.Equal(t, ErrAmbiguousPrefix{Prefix: "-"}, err)
+	})
+}
@@ -10,6 +10,9 @@ Package flagparser implements a flexible command line parser.
 [NewParser] configures GNU-style defaults: short options use `-`, long options
 use `--`, the options-arguments separator is `--`, and option permutation is
 enabled. You can override any of these defaults to parse non-GNU command lines.
+The [NewGoParser], [NewPOSIXParser], [NewWindowsParser], and [NewDigParser]
+presets configure other common conventions, while [NewGetoptParser] creates
+a [*Parser] from a getopt_long optstring and long options.
 
 To parse arguments, you need to:
 
@@ -23,7 +26,11 @@ To parse arguments, you need to:
 
  4. Invoke [*Parser.Parse] passing it `os.Args[1:]`.
 
-The [*Parser.Parse] method returns a slice of [Value].
+The [*Parser.Parse] method returns a slice of [Value]. Use [*Parser.ParseSeq]
+instead to iterate over the values as soon as the parser recognizes them. Use
+[*Parser.ParseLine] to parse a line that the user is still typing and
+[*Parser.Classify] to colorize the command line arguments by role. Use
+[*Parser.Explain] to understand how the parser handles each argument.
 
 # Options-Arguments Separator
 
@@ -69,6 +76,11 @@ Each [Option] has its own [OptionType], which is one of these values:
     `-xzf FILE`) or directly after the option (`-xzfFILE`) -- note that
     even though the latter may be confusing it is a GNU extension.
 
+ 7. [OptionTypeGroupableArgumentOptional]: like the previous section but the
+    argument is optional and must be provided directly after the option
+    (e.g., `-cVALUE`). Omitting the value (e.g., `-c`) causes the default
+    value to be used. This is the `c::` getopt optstring GNU extension.
+
 # Option Prefixes
 
 Each [Option] can define its own parsing prefix. Generally, it is
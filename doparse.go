@@ -8,7 +8,7 @@ package flagparser
 
 import (
 	"fmt"
-	"io"
+	"log/slog"
 	"strings"
 
 	"github.com/bassosimone/flagscanner"
@@ -48,90 +48,263 @@ func (err ErrOptionRequiresArgument) Error() string {
 	return fmt.Sprintf("option requires an argument: %s%s", err.Option.Prefix, err.Option.Name)
 }
 
-// parseDebugWriter is only used by tests to surface parsing steps.
-var parseDebugWriter = io.Discard
+// logTokenClassified emits the "token classified" event.
+func logTokenClassified(cfg *config, tok flagscanner.Token, kind string) {
+	logger := cfg.debugLogger()
+	if logger == nil {
+		return
+	}
+	logger.Debug("token classified",
+		slog.Int("index", tok.Index()), slog.String("kind", kind), slog.String("token", tok.String()))
+}
 
-func doParse(cfg *config, input *deque[flagscanner.Token], options, positionals *deque[Value]) error {
-	// Know when to treat everything else as positional
-	var onlypositionals bool
+// logOptionResolved emits the "option resolved" event.
+func logOptionResolved(cfg *config, tok flagscanner.Token, option *Option) {
+	logger := cfg.debugLogger()
+	if logger == nil {
+		return
+	}
+	logger.Debug("option resolved",
+		slog.Int("index", tok.Index()), slog.String("option", option.Prefix+option.Name), slog.String("token", tok.String()))
+}
+
+// logValueConsumed emits the "value consumed from next token" event.
+func logValueConsumed(cfg *config, tok flagscanner.Token, option *Option) {
+	logger := cfg.debugLogger()
+	if logger == nil {
+		return
+	}
+	logger.Debug("value consumed from next token",
+		slog.Int("index", tok.Index()), slog.String("option", option.Prefix+option.Name), slog.String("value", tok.String()))
+}
+
+// logOptionRejected emits the "option rejected" event.
+func logOptionRejected(cfg *config, tok flagscanner.Token, err error) {
+	logger := cfg.debugLogger()
+	if logger == nil {
+		return
+	}
+	logger.Debug("option rejected",
+		slog.Int("index", tok.Index()), slog.String("token", tok.String()), slog.String("error", err.Error()))
+}
+
+// logPermutationStopped emits the "permutation stopped" event.
+func logPermutationStopped(cfg *config, tok flagscanner.Token, reason string) {
+	logger := cfg.debugLogger()
+	if logger == nil {
+		return
+	}
+	logger.Debug("permutation stopped", slog.Int("index", tok.Index()), slog.String("reason", reason))
+}
+
+// logEarlyOptionFound emits the "early option short-circuit" event.
+func logEarlyOptionFound(cfg *config, tok flagscanner.Token, option *Option) {
+	logger := cfg.debugLogger()
+	if logger == nil {
+		return
+	}
+	logger.Debug("early option short-circuit",
+		slog.Int("index", tok.Index()), slog.String("option", option.Prefix+option.Name))
+}
+
+// parseState is the state of the [doParseStep] state machine.
+type parseState struct {
+	// onlypositionals indicates that we treat everything else as positional.
+	onlypositionals bool
+}
 
-	// Attempt to consume all the available tokens
-	for !input.Empty() {
-		// Get the current token and advance
-		cur, _ := input.Front()
-		input.PopFront()
-		fmt.Fprintf(parseDebugWriter, "\nprocessing token: %+v\n", cur)
+// parseStep describes what [*parseDriver.step] consumed and parsed.
+type parseStep struct {
+	// consumed contains the token at the front of the input followed, when
+	// the last option takes it as its value, by the next token (e.g., `FILE`
+	// in `-xvf FILE`), since only the last option parsed from a token may
+	// consume the following token.
+	consumed []flagscanner.Token
 
-		// Decide what to do depending on the token type
-		switch cur := cur.(type) {
+	// options contains the options we parsed. On error, it contains the options
+	// preceding the error within the same group (e.g., `-x` in `-xq`).
+	options []Value
 
-		// On positional argument, stop parsing if permutation is disabled
-		case flagscanner.PositionalArgumentToken:
+	// positionals contains the positional arguments and separators we parsed.
+	positionals []Value
+
+	// err is the error that occurred, if any.
+	err error
+}
+
+// valueOf returns the option whose value is the second consumed token, if any,
+// which we only know when the step succeeded, because, on error, the option
+// consuming the token may be missing from the options (e.g., `-xo FILE` when
+// `FILE` is not a valid value for `-o`).
+func (step parseStep) valueOf() *Option {
+	if step.err != nil || len(step.consumed) < 2 || len(step.options) <= 0 {
+		return nil
+	}
+	value, ok := step.options[len(step.options)-1].(ValueOption)
+	if !ok {
+		return nil
+	}
+	return value.Option
+}
+
+// parseDriver drives the [doParseStep] state machine one token at a time,
+// accumulating the options and positional arguments, and reports what each
+// step consumed and parsed, such that [*Parser.Parse] and the methods that
+// need to observe each step (e.g., [*Parser.ParseSeq]) share the same code.
+type parseDriver struct {
+	cfg         *config
+	input       *deque[flagscanner.Token]
+	options     *deque[Value]
+	positionals *deque[Value]
+	state       parseState
+}
+
+// newParseDriver creates a [*parseDriver] for parsing the given tokens.
+func newParseDriver(cfg *config, tokens []flagscanner.Token) *parseDriver {
+	return &parseDriver{
+		cfg:         cfg,
+		input:       &deque[flagscanner.Token]{values: tokens},
+		options:     &deque[Value]{},
+		positionals: &deque[Value]{},
+	}
+}
+
+// more returns whether there are tokens left to parse.
+func (dx *parseDriver) more() bool {
+	return !dx.input.Empty()
+}
+
+// step consumes the token at the front of the non-empty input, along with its
+// argument, if any, and returns what it consumed and parsed, also on error.
+//
+// Unlike [doParseStep], we parse early options like any other option, which only
+// matters to [*Parser.Classify], because the other methods use [earlyParse]
+// first and never parse the command line when there is an early option.
+func (dx *parseDriver) step() parseStep {
+	var (
+		before          = dx.input.values
+		firstOption     = len(dx.options.values)
+		firstPositional = len(dx.positionals.values)
+		err             error
+	)
+	if value, found := dx.earlyOption(); found {
+		dx.input.PopFront()
+		dx.options.PushBack(value)
+	} else {
+		err = doParseStep(dx.cfg, &dx.state, dx.input, dx.options, dx.positionals)
+	}
+	return parseStep{
+		consumed:    before[:len(before)-len(dx.input.values)],
+		options:     dx.options.values[firstOption:],
+		positionals: dx.positionals.values[firstPositional:],
+		err:         err,
+	}
+}
+
+// earlyOption returns the early option at the front of the input, if any.
+func (dx *parseDriver) earlyOption() (Value, bool) {
+	tok, ok := dx.input.values[0].(flagscanner.OptionToken)
+	if !ok || dx.state.onlypositionals {
+		return nil, false
+	}
+	option, err := dx.cfg.findOption(tok, tok.Name, optionKindEarly)
+	if err != nil {
+		return nil, false
+	}
+	return ValueOption{Option: option, Tok: tok}, true
+}
+
+// doParse parses all the input tokens using a [*parseDriver].
+func doParse(cfg *config, input *deque[flagscanner.Token], options, positionals *deque[Value]) error {
+	dx := &parseDriver{cfg: cfg, input: input, options: options, positionals: positionals}
+	for dx.more() {
+		if step := dx.step(); step.err != nil {
+			return step.err
+		}
+	}
+	return nil
+}
+
+// doParseStep consumes the token at the front of the non-empty input, along
+// with its argument, if any, and adds the resulting values to the deques.
+func doParseStep(cfg *config, state *parseState, input *deque[flagscanner.Token], options, positionals *deque[Value]) error {
+	// Get the current token and advance
+	cur, _ := input.Front()
+	input.PopFront()
+
+	// Decide what to do depending on the token type
+	switch cur := cur.(type) {
+
+	// On positional argument, stop parsing if permutation is disabled
+	case flagscanner.PositionalArgumentToken:
+		value := ValuePositionalArgument{
+			Tok:   cur,
+			Value: cur.Value,
+		}
+		positionals.PushBack(value)
+		logTokenClassified(cfg, cur, "positional")
+		if cfg.disablePermute() {
+			logPermutationStopped(cfg, cur, "no-permute")
+			state.onlypositionals = true
+		}
+		return nil
+
+	// Stop parsing if we encounter the options-arguments separator
+	case flagscanner.OptionsArgumentsSeparatorToken:
+		value := ValueOptionsArgumentsSeparator{
+			Tok:       cur,
+			Separator: cur.Separator,
+		}
+		positionals.PushBack(value)
+		logTokenClassified(cfg, cur, "separator")
+		logPermutationStopped(cfg, cur, "separator")
+		state.onlypositionals = true
+		return nil
+
+	// OK, we've got an option, we're definitely interested
+	case flagscanner.OptionToken:
+		// When we're treating everything as positional, just short-circuit it
+		if state.onlypositionals {
 			value := ValuePositionalArgument{
 				Tok:   cur,
-				Value: cur.Value,
+				Value: cur.String(),
 			}
 			positionals.PushBack(value)
-			fmt.Fprintf(parseDebugWriter, "added positional argument value: %+v\n", value)
-			if cfg.disablePermute() {
-				fmt.Fprint(parseDebugWriter, "no permute: starting to treat everything as positional\n")
-				onlypositionals = true
+			logTokenClassified(cfg, cur, "option-as-positional")
+			return nil
+		}
+		logTokenClassified(cfg, cur, "option")
+
+		// Switch on the kind of flag based on standalone vs groupable vs early.
+		//
+		// Note that we can take the early path if an option prefix only exists for early
+		// options (for example if we use `+` for long options, `-` for short
+		// options but we also want to handle `--help` as an early option).
+		optkind := cfg.prefixKind(cur.Prefix)
+		switch {
+		case optkind.isStandalone():
+			if err := doParseStandaloneOption(cfg, cur, input, options); err != nil {
+				logOptionRejected(cfg, cur, err)
+				return err
 			}
-			continue
 
-		// Stop parsing if we encounter the options-arguments separator
-		case flagscanner.OptionsArgumentsSeparatorToken:
-			value := ValueOptionsArgumentsSeparator{
-				Tok:       cur,
-				Separator: cur.Separator,
-			}
-			positionals.PushBack(value)
-			fmt.Fprintf(parseDebugWriter, "added options-arguments separator value: %+v\n", value)
-			fmt.Fprint(parseDebugWriter, "seen separator: starting to treat everything as positional\n")
-			onlypositionals = true
-			continue
-
-		// OK, we've got an option, we're definitely interested
-		case flagscanner.OptionToken:
-			// When we're treating everything as positional, just short-circuit it
-			if onlypositionals {
-				value := ValuePositionalArgument{
-					Tok:   cur,
-					Value: cur.String(),
-				}
-				positionals.PushBack(value)
-				fmt.Fprintf(parseDebugWriter, "added option as positional value: %+v\n", value)
-				continue
+		case optkind.isGroupable():
+			if err := doParseGroupableOption(cfg, cur, input, options); err != nil {
+				logOptionRejected(cfg, cur, err)
+				return err
 			}
 
-			// Switch on the kind of flag based on standalone vs groupable vs early.
-			//
-			// Note that we can take the early path if an option prefix only exists for early
-			// options (for example if we use `+` for long options, `-` for short
-			// options but we also want to handle `--help` as an early option).
-			optkind := cfg.prefixes[cur.Prefix]
-			switch {
-			case optkind.isStandalone():
-				if err := doParseStandaloneOption(cfg, cur, input, options); err != nil {
-					return err
-				}
-
-			case optkind.isGroupable():
-				if err := doParseGroupableOption(cfg, cur, input, options); err != nil {
-					return err
-				}
-
-			case optkind.isEarly():
-				// So, if we end up here it means that we have seen a token with a prefix
-				// used for early options only. However, conceptually speaking, introducing
-				// a prefix for early options implies that the prefix exist. As such, we
-				// treat this corner case as an unknown option with a known prefix.
-				fmt.Fprintf(parseDebugWriter, "error: no early|groupable option for token: %+v\n", cur)
-				return ErrUnknownOption{Name: cur.Name, Prefix: cur.Prefix, Token: cur}
+		case optkind.isEarly():
+			// So, if we end up here it means that we have seen a token with a prefix
+			// used for early options only. However, conceptually speaking, introducing
+			// a prefix for early options implies that the prefix exist. As such, we
+			// treat this corner case as an unknown option with a known prefix.
+			err := ErrUnknownOption{Name: cur.Name, Prefix: cur.Prefix, Token: cur}
+			logOptionRejected(cfg, cur, err)
+			return err
 
-			default:
-				panic(fmt.Sprintf("unhandled option type: %d", optkind))
-			}
+		default:
+			panic(fmt.Sprintf("unhandled option type: %d", optkind))
 		}
 	}
 	return nil
@@ -141,24 +314,24 @@ func doParseStandaloneOption(
 	cfg *config, cur flagscanner.OptionToken, input *deque[flagscanner.Token], options *deque[Value]) error {
 	// The option may contain a value, account for this
 	var optname, optvalue string
-	index := strings.Index(cur.Name, "=")
+	delimiter := cfg.optionValueDelimiter()
+	index := strings.Index(cur.Name, delimiter)
 	if index > 0 {
 		optname = cur.Name[:index]
-		optvalue = cur.Name[index+1:]
+		optvalue = cur.Name[index+len(delimiter):]
 	} else {
 		optname = cur.Name
 	}
-	fmt.Fprintf(parseDebugWriter, "optname=%q, optvalue=%q\n", optname, optvalue)
 
 	// Obtain the option given its name and prefix
 	option, err := cfg.findOption(cur, optname, optionKindStandalone)
 	if err != nil {
-		fmt.Fprintf(parseDebugWriter, "error: cannot find standalone option: %+q\n", optname)
 		return err
 	}
-	fmt.Fprintf(parseDebugWriter, "found option: %+v\n", option)
+	logOptionResolved(cfg, cur, option)
 
 	// Specialize handling depending on the option type
+	var explicit bool
 	switch option.Type {
 	case OptionTypeStandaloneArgumentNone:
 		if optname != cur.Name { // account for `--option=VALUE` case
@@ -166,11 +339,24 @@ func doParseStandaloneOption(
 		}
 
 	case OptionTypeStandaloneArgumentOptional:
-		if optvalue == "" {
+		// Distinguish `--option` and `--option=` from `--option=VALUE` unless
+		// the option asks us to keep an explicitly empty value
+		explicit = optname != cur.Name && (optvalue != "" || option.KeepEmptyValue)
+		if optname == cur.Name && option.SeparateArgument != nil { // account for opt-in `--option VALUE` case
+			tok, _ := input.Front()
+			if arg, ok := tok.(flagscanner.PositionalArgumentToken); ok && option.SeparateArgument(arg.Value) {
+				input.PopFront()
+				optvalue = arg.Value
+				explicit = true
+				logValueConsumed(cfg, arg, option)
+			}
+		}
+		if !explicit {
 			optvalue = option.DefaultValue
 		}
 
 	case OptionTypeStandaloneArgumentRequired:
+		explicit = true
 		if optname == cur.Name { // account for `--option VALUE` case
 			if input.Empty() {
 				return ErrOptionRequiresArgument{Option: option, Token: cur}
@@ -178,6 +364,7 @@ func doParseStandaloneOption(
 			tok, _ := input.Front()
 			input.PopFront()
 			optvalue = tok.String()
+			logValueConsumed(cfg, tok, option)
 		}
 
 	default:
@@ -185,9 +372,17 @@ func doParseStandaloneOption(
 	}
 
 	// Create and add the option
-	value := ValueOption{Option: option, Tok: cur, Value: optvalue}
+	value := ValueOption{
+		Option:    option,
+		Tok:       cur,
+		Value:     optvalue,
+		Explicit:  explicit,
+		Delimiter: cfg.parser.OptionValueDelimiter,
+	}
+	if err := validateOptionValue(value); err != nil {
+		return err
+	}
 	options.PushBack(value)
-	fmt.Fprintf(parseDebugWriter, "added option value: %+v\n", value)
 	return nil
 }
 
@@ -198,23 +393,25 @@ func doParseGroupableOption(
 		// Extract the option name and advance
 		optname := otokname[0]
 		otokname = otokname[1:]
-		fmt.Fprintf(parseDebugWriter, "optname=%q\n", string(optname))
 
 		// Obtain the option given its name and prefix
 		option, err := cfg.findOption(cur, string(optname), optionKindGroupable)
 		if err != nil {
-			fmt.Fprintf(parseDebugWriter, "error: cannot find groupable option: %q\n", string(optname))
 			return err
 		}
-		fmt.Fprintf(parseDebugWriter, "found option: %+v\n", option)
+		logOptionResolved(cfg, cur, option)
 
 		// Specialize handling depending on option type
-		var optvalue string
+		var (
+			optvalue string
+			explicit bool
+		)
 		switch option.Type {
 		case OptionTypeGroupableArgumentNone:
 			// nothing
 
 		case OptionTypeGroupableArgumentRequired:
+			explicit = true
 			switch {
 			case len(otokname) > 0: // the `-vfFILE` GNU-compatible case
 				optvalue = otokname
@@ -224,19 +421,30 @@ func doParseGroupableOption(
 				tok, _ := input.Front()
 				input.PopFront()
 				optvalue = tok.String()
+				logValueConsumed(cfg, tok, option)
 
 			default:
 				return ErrOptionRequiresArgument{Option: option, Token: cur}
 			}
 
+		case OptionTypeGroupableArgumentOptional:
+			explicit = len(otokname) > 0 // only the `-vcVALUE` case
+			optvalue = otokname
+			otokname = ""
+			if !explicit {
+				optvalue = option.DefaultValue
+			}
+
 		default:
 			panic(fmt.Sprintf("unhandled option type: %d", option.Type))
 		}
 
 		// Create and add the option
-		value := ValueOption{Option: option, Tok: cur, Value: optvalue}
+		value := ValueOption{Option: option, Tok: cur, Value: optvalue, Explicit: explicit}
+		if err := validateOptionValue(value); err != nil {
+			return err
+		}
 		options.PushBack(value)
-		fmt.Fprintf(parseDebugWriter, "added option value: %+v\n", value)
 	}
 	return nil
 }
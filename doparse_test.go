@@ -109,10 +109,56 @@ func flattenValues(values []Value) []string {
 	return out
 }
 
+func Test_parseDriver(t *testing.T) {
+	t.Run("we report the consumed tokens and the parsed values", func(t *testing.T) {
+		cfg := newTestDoParseConfig()
+		dx := newParseDriver(cfg, []flagscanner.Token{
+			flagscanner.OptionToken{Idx: 0, Prefix: "-", Name: "z"},
+			flagscanner.OptionToken{Idx: 1, Prefix: "--", Name: "file"},
+			flagscanner.PositionalArgumentToken{Idx: 2, Value: "x.txt"},
+		})
+		step := dx.step()
+		assert.NoError(t, step.err)
+		assert.Len(t, step.consumed, 1)
+		assert.Nil(t, step.valueOf())
+		step = dx.step()
+		assert.NoError(t, step.err)
+		assert.Len(t, step.consumed, 2)
+		assert.Equal(t, []string{"--file", "x.txt"}, flattenValues(step.options))
+		assert.Equal(t, cfg.options["file"], step.valueOf())
+		assert.False(t, dx.more())
+	})
+
+	t.Run("we report the values preceding the error", func(t *testing.T) {
+		cfg := newTestDoParseConfig()
+		cfg.options["x"].AllowedValues = []string{"a"}
+		dx := newParseDriver(cfg, []flagscanner.Token{
+			flagscanner.OptionToken{Idx: 0, Prefix: "-", Name: "zx"},
+			flagscanner.PositionalArgumentToken{Idx: 1, Value: "b"},
+		})
+		step := dx.step()
+		assert.Error(t, step.err)
+		assert.Len(t, step.consumed, 2)
+		assert.Equal(t, []string{"-z"}, flattenValues(step.options))
+		assert.Nil(t, step.valueOf())
+	})
+
+	t.Run("we parse early options as options", func(t *testing.T) {
+		cfg := newTestDoParseConfig()
+		cfg.options["help"] = &Option{Prefix: "--", Name: "help", Type: OptionTypeEarlyArgumentNone}
+		dx := newParseDriver(cfg, []flagscanner.Token{
+			flagscanner.OptionToken{Idx: 0, Prefix: "--", Name: "help"},
+		})
+		step := dx.step()
+		assert.NoError(t, step.err)
+		assert.Equal(t, []string{"--help"}, flattenValues(step.options))
+	})
+}
+
 func Test_doParse(t *testing.T) {
 	t.Run("permute keeps parsing options after a positional", func(t *testing.T) {
 		cfg := newTestDoParseConfig()
-		cfg.parser.DisablePermute = false
+		cfg.permuteDisabled = false
 		opts, pos, err := parseTokens(cfg, []flagscanner.Token{
 			flagscanner.PositionalArgumentToken{Idx: 1, Value: "file1.txt"},
 			flagscanner.OptionToken{Idx: 2, Prefix: "--", Name: "verbose"},
@@ -126,7 +172,7 @@ func Test_doParse(t *testing.T) {
 
 	t.Run("disable permute turns later options into positionals", func(t *testing.T) {
 		cfg := newTestDoParseConfig()
-		cfg.parser.DisablePermute = true
+		cfg.permuteDisabled = true
 		opts, pos, err := parseTokens(cfg, []flagscanner.Token{
 			flagscanner.PositionalArgumentToken{Idx: 1, Value: "file1.txt"},
 			flagscanner.OptionToken{Idx: 2, Prefix: "--", Name: "verbose"},
@@ -138,7 +184,7 @@ func Test_doParse(t *testing.T) {
 
 	t.Run("separator turns later options into positionals", func(t *testing.T) {
 		cfg := newTestDoParseConfig()
-		cfg.parser.DisablePermute = false
+		cfg.permuteDisabled = false
 		opts, pos, err := parseTokens(cfg, []flagscanner.Token{
 			flagscanner.OptionToken{Idx: 1, Prefix: "--", Name: "verbose"},
 			flagscanner.OptionsArgumentsSeparatorToken{Idx: 2, Separator: "--"},
@@ -152,7 +198,7 @@ func Test_doParse(t *testing.T) {
 
 	t.Run("groupable and standalone option arguments", func(t *testing.T) {
 		cfg := newTestDoParseConfig()
-		cfg.parser.DisablePermute = false
+		cfg.permuteDisabled = false
 		opts, pos, err := parseTokens(cfg, []flagscanner.Token{
 			flagscanner.OptionToken{Idx: 1, Prefix: "-", Name: "zx"},
 			flagscanner.PositionalArgumentToken{Idx: 2, Value: "file1.txt"},
@@ -166,20 +212,107 @@ func Test_doParse(t *testing.T) {
 
 	t.Run("optional argument default and explicit value", func(t *testing.T) {
 		cfg := newTestDoParseConfig()
-		cfg.parser.DisablePermute = false
+		cfg.permuteDisabled = false
 		opts, pos, err := parseTokens(cfg, []flagscanner.Token{
 			flagscanner.OptionToken{Idx: 1, Prefix: "--", Name: "http"},
 			flagscanner.OptionToken{Idx: 2, Prefix: "--", Name: "http=2.0"},
 		})
 		assert.NoError(t, err)
-		assert.Equal(t, []string{"--http=1.1", "--http=2.0"}, opts)
+		assert.Equal(t, []string{"--http", "--http=2.0"}, opts)
 		assert.Empty(t, pos)
 	})
+
+	t.Run("optional argument empty value policy", func(t *testing.T) {
+		cfg := newTestDoParseConfig()
+		cfg.options["prefix"] = &Option{
+			DefaultValue:   "/usr/local",
+			KeepEmptyValue: true,
+			Prefix:         "--",
+			Name:           "prefix",
+			Type:           OptionTypeStandaloneArgumentOptional,
+		}
+		input := &deque[flagscanner.Token]{values: []flagscanner.Token{
+			flagscanner.OptionToken{Idx: 1, Prefix: "--", Name: "http="},
+			flagscanner.OptionToken{Idx: 2, Prefix: "--", Name: "prefix"},
+			flagscanner.OptionToken{Idx: 3, Prefix: "--", Name: "prefix="},
+		}}
+		var options, positionals deque[Value]
+		err := doParse(cfg, input, &options, &positionals)
+		assert.NoError(t, err)
+
+		type result struct {
+			Value    string
+			Explicit bool
+		}
+		var got []result
+		for _, value := range options.values {
+			vo := value.(ValueOption)
+			got = append(got, result{Value: vo.Value, Explicit: vo.Explicit})
+		}
+		expect := []result{
+			{Value: "1.1", Explicit: false},
+			{Value: "/usr/local", Explicit: false},
+			{Value: "", Explicit: true},
+		}
+		assert.Equal(t, expect, got)
+		assert.Equal(t, []string{"--http", "--prefix", "--prefix="}, flattenValues(options.values))
+	})
+
+	t.Run("optional argument from the next argument", func(t *testing.T) {
+		cfg := newTestDoParseConfig()
+		cfg.options["color"] = &Option{
+			DefaultValue:     "auto",
+			SeparateArgument: MatchValues("always", "never"),
+			Prefix:           "--",
+			Name:             "color",
+			Type:             OptionTypeStandaloneArgumentOptional,
+		}
+		opts, pos, err := parseTokens(cfg, []flagscanner.Token{
+			flagscanner.OptionToken{Idx: 1, Prefix: "--", Name: "color"},
+			flagscanner.PositionalArgumentToken{Idx: 2, Value: "always"},
+			flagscanner.OptionToken{Idx: 3, Prefix: "--", Name: "color"},
+			flagscanner.PositionalArgumentToken{Idx: 4, Value: "file.txt"},
+			flagscanner.OptionToken{Idx: 5, Prefix: "--", Name: "color=never"},
+			flagscanner.PositionalArgumentToken{Idx: 6, Value: "never"},
+			flagscanner.OptionToken{Idx: 7, Prefix: "--", Name: "color"},
+			flagscanner.OptionToken{Idx: 8, Prefix: "--", Name: "verbose"},
+			flagscanner.OptionToken{Idx: 9, Prefix: "--", Name: "color"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"--color=always",
+			"--color=",
+			"--color=never",
+			"--color=",
+			"--verbose",
+			"--color=",
+		}, opts)
+		assert.Equal(t, []string{"file.txt", "never"}, pos)
+	})
+
+	t.Run("groupable optional argument", func(t *testing.T) {
+		cfg := newTestDoParseConfig()
+		cfg.options["c"] = &Option{
+			DefaultValue: "auto",
+			Prefix:       "-",
+			Name:         "c",
+			Type:         OptionTypeGroupableArgumentOptional,
+		}
+		opts, pos, err := parseTokens(cfg, []flagscanner.Token{
+			flagscanner.OptionToken{Idx: 1, Prefix: "-", Name: "zc"},
+			flagscanner.PositionalArgumentToken{Idx: 2, Value: "never"},
+			flagscanner.OptionToken{Idx: 3, Prefix: "-", Name: "zcnever"},
+			flagscanner.OptionToken{Idx: 4, Prefix: "-", Name: "cz"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"-z", "-c", "-z", "-cnever", "-cz"}, opts)
+		assert.Equal(t, []string{"never"}, pos)
+	})
 }
 
 func Test_doParse_errors(t *testing.T) {
 	cfg := newTestDoParseConfig()
-	cfg.parser.DisablePermute = false
+	cfg.permuteDisabled = false
 
 	t.Run("unknown option", func(t *testing.T) {
 		_, _, err := parseTokens(cfg, []flagscanner.Token{
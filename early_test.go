@@ -104,9 +104,14 @@ func Test_earlyFind(t *testing.T) {
 		},
 	}
 
+	cfg, err := newConfig(&Parser{Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			value, found := earlyParse(options, tc.tokens, false)
+			value, found := earlyParse(cfg, tc.tokens)
 			expectFound := tc.expect != nil
 			assert.True(t, found == expectFound)
 			assert.Equal(t, tc.expect, value)
@@ -7,9 +7,11 @@
 package flagparser_test
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"math"
+	"strings"
 
 	"github.com/bassosimone/flagparser"
 	"github.com/bassosimone/runtimex"
@@ -549,7 +551,7 @@ func Example_digParsingSuccessWithMixedPrefixes() {
 	// Output:
 	// [-p 53]
 	// [+short]
-	// [+bufsize=1024]
+	// [+bufsize]
 	// [@8.8.8.8]
 	// [IN]
 	// [A]
@@ -588,7 +590,7 @@ func Example_curlParsingSuccessLongWithOptionalValueNotPresent() {
 	}
 
 	// Output:
-	// [--fail=true]
+	// [--fail]
 	// [--output index.html]
 	// [https://www.example.com/]
 }
@@ -668,3 +670,476 @@ func Example_curlParsingSuccessShortWithSpaceAndDashValue() {
 	// [-o -]
 	// [https://www.example.com/]
 }
+
+// Successful parsing of configure-like invocation where an empty optional
+// argument explicitly clears a setting rather than selecting the default.
+func Example_configureParsingSuccessWithExplicitEmptyValue() {
+	// Define a parser where `--prefix=` keeps the empty value.
+	parser := flagparser.NewParser()
+	parser.AddOption(&flagparser.Option{
+		DefaultValue:   "/usr/local",
+		KeepEmptyValue: true,
+		Prefix:         "--",
+		Name:           "prefix",
+		Type:           flagparser.OptionTypeStandaloneArgumentOptional,
+	})
+
+	// Define the argument vector to parse; the second `--prefix` is explicitly empty.
+	argv := []string{"configure", "--prefix", "--prefix="}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values to stdout along with whether they are explicit
+	for _, value := range values {
+		option := value.(flagparser.ValueOption)
+		fmt.Printf("%+v %q %v\n", value.Strings(), option.Value, option.Explicit)
+	}
+
+	// Output:
+	// [--prefix] "/usr/local" false
+	// [--prefix=] "" true
+}
+
+// Successful parsing of ls-like invocation where an optional argument may
+// also be a separate token when it belongs to a set of allowed values.
+func Example_lsParsingSuccessWithSeparateOptionalArgument() {
+	// Define a parser where `--color` also accepts `--color WHEN`.
+	parser := flagparser.NewParser()
+	parser.SetMinMaxPositionalArguments(0, math.MaxInt)
+	parser.AddOption(&flagparser.Option{
+		DefaultValue:     "always",
+		SeparateArgument: flagparser.MatchValues("always", "auto", "never"),
+		Prefix:           "--",
+		Name:             "color",
+		Type:             flagparser.OptionTypeStandaloneArgumentOptional,
+	})
+
+	// Define the argument vector to parse; `never` is consumed by `--color`
+	// while `src` is not an allowed value and remains a positional argument.
+	argv := []string{"ls", "--color", "never", "--color", "src", "--color=auto"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values to stdout
+	for _, value := range values {
+		fmt.Printf("%+v\n", value.Strings())
+	}
+
+	// Output:
+	// [--color=never]
+	// [--color=]
+	// [--color=auto]
+	// [src]
+}
+
+// Failing parsing of tar-like invocation where an option is given a value
+// that is not included in its allowed values.
+func Example_tarParsingFailureWithValueNotAllowed() {
+	// Define a parser where `--compress` only accepts a few values.
+	parser := flagparser.NewParser()
+	parser.AddOption(&flagparser.Option{
+		DefaultValue:  "gzip",
+		AllowedValues: []string{"gzip", "bzip2", "xz"},
+		Prefix:        "--",
+		Name:          "compress",
+		Type:          flagparser.OptionTypeStandaloneArgumentOptional,
+	})
+
+	// Define the argument vector to parse
+	argv := []string{"tar", "--compress=lz4"}
+
+	// Parse the options; this is where `lz4` causes a failure
+	values, err := parser.Parse(argv[1:])
+	runtimex.Assert(len(values) <= 0 && err != nil)
+
+	// Print the error value
+	fmt.Printf("%s\n", err.Error())
+
+	// Output:
+	// invalid value for option --compress: "lz4" (allowed values: gzip, bzip2, xz)
+}
+
+// Successful parsing of cp-like invocation with named positional argument
+// slots, where the last positional is the destination.
+func Example_cpParsingSuccessWithNamedPositionalArguments() {
+	// Define a parser with `SRC...` and `DST` positional argument slots.
+	parser := flagparser.NewParser()
+	parser.AddOptionWithArgumentNone('v', "verbose")
+	parser.AddPositionalArgument(
+		&flagparser.PositionalArgument{Name: "SRC", Variadic: true},
+		&flagparser.PositionalArgument{Name: "DST"},
+	)
+
+	// Define the argument vector to parse
+	argv := []string{"cp", "a.txt", "-v", "b.txt", "dir/"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values to stdout along with their slot, if any
+	for _, value := range values {
+		if arg, ok := value.(flagparser.ValuePositionalArgument); ok {
+			fmt.Printf("%+v %s\n", value.Strings(), arg.Argument.Name)
+			continue
+		}
+		fmt.Printf("%+v\n", value.Strings())
+	}
+
+	// Output:
+	// [-v]
+	// [a.txt] SRC
+	// [b.txt] SRC
+	// [dir/] DST
+}
+
+// Failing parsing of cp-like invocation where the destination is missing.
+func Example_cpParsingFailureWithMissingPositionalArgument() {
+	// Define a parser with `SRC...` and `DST` positional argument slots.
+	parser := flagparser.NewParser()
+	parser.AddOptionWithArgumentNone('v', "verbose")
+	parser.AddPositionalArgument(
+		&flagparser.PositionalArgument{Name: "SRC", Variadic: true},
+		&flagparser.PositionalArgument{Name: "DST"},
+	)
+
+	// Define the argument vector to parse
+	argv := []string{"cp", "-v", "a.txt"}
+
+	// Parse the options; this is where the missing `DST` causes a failure
+	values, err := parser.Parse(argv[1:])
+	runtimex.Assert(len(values) <= 0 && err != nil)
+
+	// Print the error value
+	fmt.Printf("%s\n", err.Error())
+
+	// Output:
+	// missing DST
+}
+
+// Normalization of a curl-like invocation into a canonical command line
+// that is suitable for logging and that we can safely paste into a shell.
+func Example_curlNormalizeAndQuote() {
+	// Define a parser accepting curl-like command line options.
+	parser := flagparser.NewParser()
+	parser.SetMinMaxPositionalArguments(1, math.MaxInt)
+	parser.AddOptionWithArgumentNone('f', "fail")
+	parser.AddOptionWithArgumentRequired('H', "header")
+	parser.AddOptionWithArgumentNone('L', "location")
+	parser.AddOptionWithArgumentRequired('o', "output")
+	parser.AddOptionWithArgumentNone('s', "silent")
+
+	// Define the argument vector to parse
+	argv := []string{"curl", "https://www.example.com/", "-sfLo", "index.html", "-H", "Accept: */*"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the canonical command line using different styles
+	fmt.Println(flagparser.QuotePOSIXShell(flagparser.Normalize(values, flagparser.NormalizeStyleLong)))
+	fmt.Println(flagparser.QuotePOSIXShell(flagparser.Normalize(values, flagparser.NormalizeStyleShort)))
+
+	// Output:
+	// --fail '--header=Accept: */*' --location --output=index.html --silent https://www.example.com/
+	// -Lfs -H 'Accept: */*' -o index.html https://www.example.com/
+}
+
+// Loading a dig-like parser from a JSON spec written by an ops team.
+func Example_digParserSpecJSON() {
+	// Define the spec, which would typically live in a file.
+	spec := `{
+  "max_positional_arguments": -1,
+  "options": [
+    {"prefix": "-", "name": "p", "type": "GroupableArgumentRequired"},
+    {"prefix": "+", "name": "short", "type": "StandaloneArgumentNone"}
+  ]
+}`
+
+	// Load the parser from the spec
+	parser, err := flagparser.LoadParserSpecJSON(strings.NewReader(spec))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Define the argument vector to parse
+	argv := []string{"dig", "+short", "example.com", "-p53"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values
+	for _, value := range values {
+		fmt.Printf("%q\n", value.Strings())
+	}
+
+	// Output:
+	// ["+short"]
+	// ["-p" "53"]
+	// ["example.com"]
+}
+
+// Parsing a Go-style command line using the Go flag package conventions,
+// where the first positional argument terminates the options.
+func Example_goParserPreset() {
+	// Define a parser following the Go flag package conventions.
+	parser := flagparser.NewGoParser()
+	parser.SetMinMaxPositionalArguments(0, math.MaxInt)
+	parser.AddOption(&flagparser.Option{Prefix: "-", Name: "o", Type: flagparser.OptionTypeStandaloneArgumentRequired})
+	parser.AddOption(&flagparser.Option{Prefix: "-", Name: "race", Type: flagparser.OptionTypeStandaloneArgumentNone})
+	parser.AddOption(&flagparser.Option{Prefix: "-", Name: "v", Type: flagparser.OptionTypeStandaloneArgumentNone})
+
+	// Define the argument vector to parse; `--race` is equivalent to `-race`
+	// and `-v` is a positional argument because it follows `./cmd/foo`.
+	argv := []string{"gobuild", "-o=foo", "--race", "./cmd/foo", "-v"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values
+	for _, value := range values {
+		fmt.Printf("%q\n", value.Strings())
+	}
+
+	// Output:
+	// ["-o" "foo"]
+	// ["-race"]
+	// ["./cmd/foo"]
+	// ["-v"]
+}
+
+// Parsing a POSIX-style command line, where only short options exist
+// and the first positional argument terminates the options.
+func Example_posixParserPreset() {
+	// Define a parser following the POSIX getopt conventions.
+	parser := flagparser.NewPOSIXParser()
+	parser.SetMinMaxPositionalArguments(1, math.MaxInt)
+	parser.AddOptionWithArgumentNone('l', "")
+	parser.AddOptionWithArgumentRequired('n', "")
+
+	// Define the argument vector to parse; `-l` is a positional
+	// argument because it follows `file.txt`.
+	argv := []string{"tool", "-n5", "file.txt", "-l"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values
+	for _, value := range values {
+		fmt.Printf("%q\n", value.Strings())
+	}
+
+	// Output:
+	// ["-n" "5"]
+	// ["file.txt"]
+	// ["-l"]
+}
+
+// Parsing a Windows-style command line, where `:` separates option
+// names and values and option names are case insensitive.
+func Example_windowsParserPreset() {
+	// Define a parser following the Windows conventions.
+	parser := flagparser.NewWindowsParser()
+	parser.SetMinMaxPositionalArguments(1, 2)
+	parser.AddOption(&flagparser.Option{Prefix: "/", Name: "?", Type: flagparser.OptionTypeEarlyArgumentNone})
+	parser.AddOption(&flagparser.Option{Prefix: "/", Name: "out", Type: flagparser.OptionTypeStandaloneArgumentRequired})
+	parser.AddOption(&flagparser.Option{Prefix: "/", Name: "y", Type: flagparser.OptionTypeStandaloneArgumentNone})
+
+	// Define the argument vector to parse; options may follow the positional arguments.
+	argv := []string{"xcopy", `C:\src`, "/OUT:log.txt", `D:\dst`, "/Y"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values along with the canonical command line
+	for _, value := range values {
+		fmt.Printf("%q\n", value.Strings())
+	}
+	fmt.Println(flagparser.Normalize(values, flagparser.NormalizeStyleLong))
+
+	// Output:
+	// ["/out" "log.txt"]
+	// ["/y"]
+	// ["C:\\src"]
+	// ["D:\\dst"]
+	// [/out:log.txt /y C:\src D:\dst]
+}
+
+// Parsing a dig-style command line, where `+` introduces standalone options,
+// `-` introduces groupable options, and `--` is not a separator.
+func Example_digParserPreset() {
+	// Define a parser following the dig conventions.
+	parser := flagparser.NewDigParser()
+	parser.SetMinMaxPositionalArguments(1, 4)
+	parser.AddOption(&flagparser.Option{Prefix: "-", Name: "p", Type: flagparser.OptionTypeGroupableArgumentRequired})
+	parser.AddOption(&flagparser.Option{Prefix: "+", Name: "short", Type: flagparser.OptionTypeStandaloneArgumentNone})
+
+	// Define the argument vector to parse
+	argv := []string{"dig", "@8.8.8.8", "+short", "example.com", "-p", "53"}
+
+	// Parse the options
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the parsed values
+	for _, value := range values {
+		fmt.Printf("%q\n", value.Strings())
+	}
+
+	// Output:
+	// ["+short"]
+	// ["-p" "53"]
+	// ["@8.8.8.8"]
+	// ["example.com"]
+}
+
+// Parsing GNU-style options defined using the standard library flag package.
+func Example_flagSetAdapter() {
+	// Define the flags using the flag package.
+	fs := flag.NewFlagSet("gzip", flag.ExitOnError)
+	keep := fs.Bool("k", false, "keep the input files")
+	verbose := fs.Bool("v", false, "be verbose")
+	suffix := fs.String("suffix", ".gz", "use the given suffix")
+
+	// Define a parser for the flags.
+	parser, err := flagparser.NewFlagSetParser(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Define the argument vector to parse; grouping and permutation work.
+	argv := []string{"gzip", "a.txt", "-kv", "--suffix", ".z", "b.txt"}
+
+	// Parse the options and apply them to the flag set
+	values, err := parser.Parse(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	args, err := flagparser.ApplyFlagSet(fs, values)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Print the flags and the positional arguments
+	fmt.Println(*keep, *verbose, *suffix, args)
+
+	// Output:
+	// true true .z [a.txt b.txt]
+}
+
+// Iterating over the values while parsing, where options are
+// available immediately and positional arguments come last.
+func Example_parseSeq() {
+	// Define a parser accepting curl-like command line options.
+	parser := flagparser.NewParser()
+	parser.SetMinMaxPositionalArguments(1, math.MaxInt)
+	parser.AddOptionWithArgumentNone('f', "fail")
+	parser.AddOptionWithArgumentRequired('o', "output")
+
+	// Define the argument vector to parse; the last option is invalid.
+	argv := []string{"curl", "https://www.example.com/", "-fo", "index.html", "--nonexistent"}
+
+	// Iterate over the values until we encounter an error
+	for value, err := range parser.ParseSeq(argv[1:]) {
+		if err != nil {
+			fmt.Println("error:", err)
+			break
+		}
+		fmt.Printf("%+v\n", value.Strings())
+	}
+
+	// Output:
+	// [-f]
+	// [-o index.html]
+	// error: unknown option: --nonexistent
+}
+
+// Parsing a line that the user is still typing inside a REPL,
+// where the last option lacks its required argument.
+func Example_parseLine() {
+	// Define a parser accepting curl-like command line options.
+	parser := flagparser.NewParser()
+	parser.SetMinMaxPositionalArguments(1, math.MaxInt)
+	parser.AddOptionWithArgumentNone('f', "fail")
+	parser.AddOptionWithArgumentRequired('o', "output")
+
+	// Define the line to parse, which does not include the program name.
+	line := `https://www.example.com/ -f --output `
+
+	// Parse the line and print each value along with its byte ranges
+	result := parser.ParseLine(line)
+	for _, value := range result.Values {
+		var texts []string
+		for _, br := range value.Ranges {
+			texts = append(texts, line[br.Start:br.End])
+		}
+		fmt.Printf("%q\n", texts)
+	}
+	fmt.Println(result.State == flagparser.LineNeedsMoreInput, result.Err)
+
+	// Output:
+	// ["-f"]
+	// ["https://www.example.com/"]
+	// true option requires an argument: --output
+}
+
+// Classifying the bytes of tar-like command line arguments by role,
+// which is useful to colorize them, where groups are split per byte.
+func Example_classify() {
+	// Define a parser accepting tar-like command line options.
+	parser := flagparser.NewParser()
+	parser.SetMinMaxPositionalArguments(0, math.MaxInt)
+	parser.AddOptionWithArgumentNone('x', "extract")
+	parser.AddOptionWithArgumentNone('v', "verbose")
+	parser.AddOptionWithArgumentRequired('f', "file")
+
+	// Define the argument vector to classify.
+	argv := []string{"tar", "-xvfFILE", "--file", "OTHER", "--nonexistent", "--", "-x"}
+
+	// Classify the arguments and print each span
+	spans, err := parser.Classify(argv[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	for idx, arg := range argv[1:] {
+		var texts []string
+		for _, span := range spans[idx] {
+			texts = append(texts, fmt.Sprintf("%s(%s)", span.Kind, arg[span.Start:span.End]))
+		}
+		fmt.Println(strings.Join(texts, " "))
+	}
+
+	// Output:
+	// option-prefix(-) group-member(x) group-member(v) group-member(f) inline-value(FILE)
+	// option-prefix(--) option-name(file)
+	// separate-value(OTHER)
+	// error(--nonexistent)
+	// separator(--)
+	// positional(-x)
+}
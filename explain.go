@@ -0,0 +1,121 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import "github.com/bassosimone/flagscanner"
+
+// ArgumentExplanation explains how [*Parser.Parse] handles a command line argument.
+type ArgumentExplanation struct {
+	// Arg is the command line argument.
+	Arg string
+
+	// Token is the token into which we scanned the argument.
+	Token flagscanner.Token
+
+	// Values contains the values we parsed from the argument, in the order in
+	// which [*Parser.Parse] returns them. For example, a group of options (e.g.,
+	// `-xvf`) produces several values, while an argument that is the value of
+	// the preceding option (e.g., `FILE` in `-f FILE`) produces no values.
+	Values []Value
+
+	// ValueOf is the option whose value is this argument, if any.
+	ValueOf *Option
+
+	// Positions contains the position of each of the Values within the slice
+	// returned by [*Parser.Parse], which shows how permutation moved them. This
+	// field is empty when parsing fails, because there is no such slice.
+	Positions []int
+
+	// Err is the error that stopped parsing at this argument, if any.
+	Err error
+}
+
+// explainedValue is a [Value] along with the index of its argument.
+type explainedValue struct {
+	Value
+	index int
+}
+
+// Explain parses the command line arguments like [*Parser.Parse] does and
+// returns an explanation for each argument, which is useful to understand how
+// the [*Parser] handles a command line (e.g., why it treats an option as a
+// positional argument or where permutation moves an option).
+//
+// When [*Parser.Parse] would fail, we return the explanations along with the
+// same error. In such a case, the explanation of the arguments following the
+// failing argument is empty. Likewise, when we find an early option, we only
+// explain the early option, because [*Parser.Parse] ignores the other arguments.
+//
+// This method returns nil explanations when the [*Parser] is not valid.
+//
+// This method does not mutate [*Parser] and is safe to call concurrently.
+//
+// The args MUST NOT include the program name.
+func (px *Parser) Explain(args []string) ([]ArgumentExplanation, error) {
+	// Create the configuration
+	cfg, err := newConfig(px)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokenize the arguments and prepare an explanation for each of them
+	tokens := px.scan(cfg, args)
+	explanations := make([]ArgumentExplanation, len(args))
+	for _, tok := range tokens {
+		explanations[tok.Index()].Arg = args[tok.Index()]
+		explanations[tok.Index()].Token = tok
+	}
+
+	// Handle the early options like Parse does
+	if value, found := earlyParse(cfg, tokens); found {
+		explanations[value.Token().Index()].Values = []Value{value}
+		explanations[value.Token().Index()].Positions = []int{0}
+		return explanations, nil
+	}
+
+	// Parse one token at a time and record the argument each option consumes
+	dx := newParseDriver(cfg, tokens)
+	for dx.more() {
+		step := dx.step()
+		if step.err != nil {
+			explanations[step.consumed[0].Index()].Err = step.err
+			explainValues(explanations, dx.options.values)
+			explainValues(explanations, dx.positionals.values)
+			return explanations, step.err
+		}
+		if option := step.valueOf(); option != nil {
+			explanations[step.consumed[1].Index()].ValueOf = option
+		}
+	}
+
+	// Check the positional arguments and permute like Parse does, using
+	// explainedValue to map each permuted value back to its argument
+	if err := px.checkPositionals(dx.positionals.values); err != nil {
+		explainValues(explanations, dx.options.values)
+		explainValues(explanations, dx.positionals.values)
+		return explanations, err
+	}
+	wrap := func(values []Value) []Value {
+		output := make([]Value, 0, len(values))
+		for _, value := range values {
+			output = append(output, explainedValue{Value: value, index: value.Token().Index()})
+		}
+		return output
+	}
+	for position, value := range permute(cfg.disablePermute(), wrap(dx.options.values), wrap(dx.positionals.values)) {
+		explained := value.(explainedValue)
+		explanations[explained.index].Values = append(explanations[explained.index].Values, explained.Value)
+		explanations[explained.index].Positions = append(explanations[explained.index].Positions, position)
+	}
+	return explanations, nil
+}
+
+// explainValues adds each value to the explanation of its argument.
+func explainValues(explanations []ArgumentExplanation, values []Value) {
+	for _, value := range values {
+		index := value.Token().Index()
+		explanations[index].Values = append(explanations[index].Values, value)
+	}
+}
@@ -0,0 +1,115 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_Explain(t *testing.T) {
+	newTarParser := func() *Parser {
+		px := NewParser()
+		px.SetMinMaxPositionalArguments(1, math.MaxInt)
+		px.AddOptionWithArgumentNone('x', "extract")
+		px.AddOptionWithArgumentRequired('f', "file")
+		px.AddEarlyOption('h', "help")
+		return px
+	}
+
+	// summary flattens an explanation for comparison.
+	type summary struct {
+		arg       string
+		values    []string
+		valueOf   string
+		positions []int
+		err       string
+	}
+	summarize := func(explanations []ArgumentExplanation) []summary {
+		output := []summary{}
+		for _, expl := range explanations {
+			entry := summary{arg: expl.Arg, positions: expl.Positions}
+			for _, value := range expl.Values {
+				entry.values = append(entry.values, value.Strings()...)
+			}
+			if expl.ValueOf != nil {
+				entry.valueOf = expl.ValueOf.Prefix + expl.ValueOf.Name
+			}
+			if expl.Err != nil {
+				entry.err = expl.Err.Error()
+			}
+			output = append(output, entry)
+		}
+		return output
+	}
+
+	t.Run("we explain groups, values, and permutation", func(t *testing.T) {
+		args := []string{"a.tar", "-xf", "FILE", "--", "-x"}
+		explanations, err := newTarParser().Explain(args)
+		assert.NoError(t, err)
+		assert.Equal(t, []summary{
+			{arg: "a.tar", values: []string{"a.tar"}, positions: []int{2}},
+			{arg: "-xf", values: []string{"-x", "-f", "FILE"}, positions: []int{0, 1}},
+			{arg: "FILE", valueOf: "-f"},
+			{arg: "--", values: []string{"--"}, positions: []int{3}},
+			{arg: "-x", values: []string{"-x"}, positions: []int{4}},
+		}, summarize(explanations))
+
+		// Make sure the positions match what Parse returns
+		values, err := newTarParser().Parse(args)
+		assert.NoError(t, err)
+		for _, expl := range explanations {
+			for idx, position := range expl.Positions {
+				assert.Equal(t, values[position], expl.Values[idx])
+			}
+		}
+	})
+
+	t.Run("we only explain the early option", func(t *testing.T) {
+		explanations, err := newTarParser().Explain([]string{"--nonexistent", "-h"})
+		assert.NoError(t, err)
+		assert.Equal(t, []summary{
+			{arg: "--nonexistent"},
+			{arg: "-h", values: []string{"-h"}, positions: []int{0}},
+		}, summarize(explanations))
+	})
+
+	t.Run("we explain the arguments preceding an error", func(t *testing.T) {
+		explanations, err := newTarParser().Explain([]string{"a.tar", "-xq", "-x"})
+		assert.EqualError(t, err, "unknown option: -q")
+		assert.Equal(t, []summary{
+			{arg: "a.tar", values: []string{"a.tar"}},
+			{arg: "-xq", values: []string{"-x"}, err: "unknown option: -q"},
+			{arg: "-x"},
+		}, summarize(explanations))
+	})
+
+	t.Run("we explain the arguments when positional arguments are missing", func(t *testing.T) {
+		explanations, err := newTarParser().Explain([]string{"-f", "FILE"})
+		assert.Equal(t, ErrTooFewPositionalArguments{Min: 1, Have: 0}, err)
+		assert.Equal(t, []summary{
+			{arg: "-f", values: []string{"-f", "FILE"}},
+			{arg: "FILE", valueOf: "-f"},
+		}, summarize(explanations))
+	})
+
+	t.Run("we assign the positional arguments to the slots", func(t *testing.T) {
+		px := newTarParser()
+		px.AddPositionalArgument(&PositionalArgument{Name: "ARCHIVE"})
+		explanations, err := px.Explain([]string{"a.tar"})
+		assert.NoError(t, err)
+		assert.Equal(t, "ARCHIVE", explanations[0].Values[0].(ValuePositionalArgument).Argument.Name)
+	})
+
+	t.Run("we return nil explanations for an invalid configuration", func(t *testing.T) {
+		px := newTarParser()
+		px.AddOption(&Option{Prefix: "--", Name: "file"})
+		explanations, err := px.Explain([]string{"-x"})
+		assert.Error(t, err)
+		assert.Nil(t, explanations)
+	})
+}
@@ -0,0 +1,87 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"flag"
+	"math"
+)
+
+// boolFlag is the interface implemented by [flag.Value] types that do
+// not require an argument (e.g., the values created by [flag.Bool]).
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// NewFlagSetParser creates a new [*Parser] following the GNU convention
+// that accepts the flags defined by the given [*flag.FlagSet].
+//
+// We map each flag to an option as follows:
+//
+//  1. flags with single-byte names use the `-` prefix and are groupable,
+//     while other flags use the `--` prefix and are standalone
+//
+//  2. boolean flags take no argument, while other flags require an argument
+//
+// We consider a flag boolean when its [flag.Value] has an IsBoolFlag method
+// returning true, like the flag package does. The returned [*Parser] allows
+// any number of positional arguments. Use [ApplyFlagSet] to apply the parsed
+// values to the [*flag.FlagSet].
+//
+// This function returns the same errors that [*Parser.Parse] returns
+// for an invalid configuration (e.g., [ErrMultipleOptionsWithSameName]).
+func NewFlagSetParser(fs *flag.FlagSet) (*Parser, error) {
+	px := NewParser()
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	fs.VisitAll(func(fx *flag.Flag) {
+		if fx.Name == "" {
+			return // cannot be used in the command line anyway
+		}
+		bf, ok := fx.Value.(boolFlag)
+		isBool := ok && bf.IsBoolFlag()
+		switch {
+		case len(fx.Name) == 1 && isBool:
+			px.AddOption(newShortOption(fx.Name[0], OptionTypeGroupableArgumentNone))
+		case len(fx.Name) == 1:
+			px.AddOption(newShortOption(fx.Name[0], OptionTypeGroupableArgumentRequired))
+		case isBool:
+			px.AddOption(newLongOption(fx.Name, OptionTypeStandaloneArgumentNone))
+		default:
+			px.AddOption(newLongOption(fx.Name, OptionTypeStandaloneArgumentRequired))
+		}
+	})
+	if _, err := newConfig(px); err != nil {
+		return nil, err
+	}
+	return px, nil
+}
+
+// ApplyFlagSet applies the values returned by a [*Parser] created
+// using [NewFlagSetParser] to the given [*flag.FlagSet].
+//
+// We set each option using [*flag.FlagSet.Set], using `true` as the value of
+// boolean flags, and we return the positional arguments. Because we do not call
+// [*flag.FlagSet.Parse], use the returned positional arguments rather than the
+// [*flag.FlagSet] Args method. We return [ErrOptionValueInvalid] when setting
+// a flag fails (e.g., because an integer flag has a non-numeric value).
+func ApplyFlagSet(fs *flag.FlagSet, values []Value) ([]string, error) {
+	args := []string{}
+	for _, value := range values {
+		switch value := value.(type) {
+		case ValueOption:
+			arg := value.Value
+			if value.Option.Type.argumentKind() == optionArgumentNone {
+				arg = "true"
+			}
+			if err := fs.Set(value.Option.Name, arg); err != nil {
+				return nil, ErrOptionValueInvalid{Option: value.Option, Value: arg, Token: value.Tok, Err: err}
+			}
+
+		case ValuePositionalArgument:
+			args = append(args, value.Value)
+		}
+	}
+	return args, nil
+}
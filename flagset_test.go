@@ -0,0 +1,130 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countFlag is a [flag.Value] that counts how many times we set it.
+type countFlag int
+
+// String implements [flag.Value].
+func (cf *countFlag) String() string {
+	return ""
+}
+
+// Set implements [flag.Value].
+func (cf *countFlag) Set(string) error {
+	*cf++
+	return nil
+}
+
+// IsBoolFlag implements [boolFlag].
+func (cf *countFlag) IsBoolFlag() bool {
+	return true
+}
+
+func TestNewFlagSetParser(t *testing.T) {
+	fs := flag.NewFlagSet("x", flag.ContinueOnError)
+	fs.Bool("v", false, "")
+	fs.String("o", "", "")
+	fs.Bool("dry-run", false, "")
+	fs.Duration("timeout", time.Second, "")
+
+	px, err := NewFlagSetParser(fs)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var got []string
+	for _, option := range px.Options {
+		got = append(got, option.Prefix+option.Name+" "+option.Type.String())
+	}
+	expect := []string{
+		"--dry-run StandaloneArgumentNone",
+		"-o GroupableArgumentRequired",
+		"--timeout StandaloneArgumentRequired",
+		"-v GroupableArgumentNone",
+	}
+	assert.Equal(t, expect, got)
+}
+
+func TestApplyFlagSet(t *testing.T) {
+	t.Run("options and positional arguments", func(t *testing.T) {
+		fs := flag.NewFlagSet("x", flag.ContinueOnError)
+		verbose := fs.Bool("v", false, "")
+		output := fs.String("o", "", "")
+		dryRun := fs.Bool("dry-run", false, "")
+		timeout := fs.Duration("timeout", time.Second, "")
+		var count countFlag
+		fs.Var(&count, "c", "")
+
+		px, err := NewFlagSetParser(fs)
+		if !assert.NoError(t, err) {
+			return
+		}
+		values, err := px.Parse([]string{"a", "-vcco", "out.txt", "b", "--timeout", "5s", "--dry-run", "--", "-c"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := ApplyFlagSet(fs, values)
+		assert.NoError(t, err)
+
+		assert.True(t, *verbose)
+		assert.Equal(t, "out.txt", *output)
+		assert.True(t, *dryRun)
+		assert.Equal(t, 5*time.Second, *timeout)
+		assert.Equal(t, countFlag(2), count)
+		assert.Equal(t, []string{"a", "b", "-c"}, args)
+		assert.Equal(t, 5, fs.NFlag())
+		assert.False(t, fs.Parsed())
+	})
+
+	t.Run("no positional arguments", func(t *testing.T) {
+		fs := flag.NewFlagSet("x", flag.ContinueOnError)
+		fs.Bool("v", false, "")
+
+		px, err := NewFlagSetParser(fs)
+		if !assert.NoError(t, err) {
+			return
+		}
+		values, err := px.Parse([]string{"-v"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := ApplyFlagSet(fs, values)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, args)
+	})
+
+	t.Run("invalid flag value", func(t *testing.T) {
+		fs := flag.NewFlagSet("x", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Int("count", 0, "")
+
+		px, err := NewFlagSetParser(fs)
+		if !assert.NoError(t, err) {
+			return
+		}
+		values, err := px.Parse([]string{"--count=many"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := ApplyFlagSet(fs, values)
+		assert.Nil(t, args)
+		var errvalue ErrOptionValueInvalid
+		if assert.True(t, errors.As(err, &errvalue)) {
+			assert.Equal(t, "count", errvalue.Option.Name)
+			assert.Equal(t, "many", errvalue.Value)
+		}
+		assert.False(t, fs.Parsed())
+	})
+}
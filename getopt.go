@@ -0,0 +1,167 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"fmt"
+	"math"
+)
+
+// GetoptArgument describes whether a [GetoptLongOption] takes an
+// argument, like the has_arg field of the getopt_long option struct.
+type GetoptArgument int
+
+const (
+	// GetoptNoArgument indicates that the option takes no argument.
+	GetoptNoArgument = GetoptArgument(iota)
+
+	// GetoptRequiredArgument indicates that the option requires an argument.
+	GetoptRequiredArgument
+
+	// GetoptOptionalArgument indicates that the option takes an optional argument.
+	GetoptOptionalArgument
+)
+
+// GetoptLongOption describes a long option for [NewGetoptParser].
+type GetoptLongOption struct {
+	// Name is the name of the long option without the `--` prefix.
+	Name string
+
+	// HasArg indicates whether the long option takes an argument.
+	HasArg GetoptArgument
+
+	// Short optionally contains the name of the equivalent short
+	// option, which must appear in the optstring and take the same
+	// kind of argument. When set, we make the two options aliases.
+	Short byte
+}
+
+// ErrInvalidGetoptSpec indicates that [NewGetoptParser] cannot
+// handle the given optstring or long option.
+type ErrInvalidGetoptSpec struct {
+	// Spec is the offending optstring or long option name.
+	Spec string
+
+	// Reason explains why the spec is invalid.
+	Reason string
+}
+
+var _ error = ErrInvalidGetoptSpec{}
+
+// Error returns a string representation of this error.
+func (err ErrInvalidGetoptSpec) Error() string {
+	return fmt.Sprintf("invalid getopt spec %q: %s", err.Spec, err.Reason)
+}
+
+// NewGetoptParser creates a new [*Parser] from a getopt_long optstring and
+// long options, such that parsing mimics the GNU C library.
+//
+// Specifically, we map the optstring as follows:
+//
+//  1. a leading `+` disables command line permutation
+//
+//  2. a leading `:` only affects how getopt reports errors, so we ignore it
+//
+//  3. `c` becomes [OptionTypeGroupableArgumentNone]
+//
+//  4. `c:` becomes [OptionTypeGroupableArgumentRequired]
+//
+//  5. `c::` becomes [OptionTypeGroupableArgumentOptional]
+//
+// We map the long options with [GetoptNoArgument], [GetoptRequiredArgument],
+// and [GetoptOptionalArgument] to [OptionTypeStandaloneArgumentNone],
+// [OptionTypeStandaloneArgumentRequired], and [OptionTypeStandaloneArgumentOptional]
+// respectively, using `--` as the prefix. Like getopt_long, we keep the explicitly
+// empty values of long options with optional arguments (e.g., `--color=`).
+//
+// The returned [*Parser] honours the POSIXLY_CORRECT environment variable, uses
+// `--` as the separator, and allows any number of positional arguments.
+//
+// We return [ErrInvalidGetoptSpec] for getopt features that we do not support,
+// such as a leading `-` in the optstring, which returns positional arguments in
+// order, and `W;`, which turns `-W foo` into `--foo`. Note that, unlike getopt_long,
+// the returned [*Parser] does not accept abbreviations of long options.
+func NewGetoptParser(optstring string, longopts []GetoptLongOption) (*Parser, error) {
+	px := &Parser{
+		DisablePermute:            false,
+		HonorPosixlyCorrect:       true,
+		MaxPositionalArguments:    math.MaxInt,
+		MinPositionalArguments:    0,
+		OptionsArgumentsSeparator: "--",
+		Options:                   []*Option{},
+	}
+
+	// Handle the optstring flags, which must precede the options.
+	spec := optstring
+	if len(spec) > 0 && spec[0] == '-' {
+		return nil, ErrInvalidGetoptSpec{Spec: optstring, Reason: "returning positional arguments in order is not supported"}
+	}
+	if len(spec) > 0 && spec[0] == '+' {
+		px.DisablePermute = true
+		spec = spec[1:]
+	}
+	if len(spec) > 0 && spec[0] == ':' {
+		spec = spec[1:]
+	}
+
+	// Create the short options.
+	shorts := make(map[byte]*Option)
+	for len(spec) > 0 {
+		name := spec[0]
+		spec = spec[1:]
+		optionType := OptionTypeGroupableArgumentNone
+		switch {
+		case len(spec) >= 2 && spec[:2] == "::":
+			optionType = OptionTypeGroupableArgumentOptional
+			spec = spec[2:]
+		case len(spec) >= 1 && spec[0] == ':':
+			optionType = OptionTypeGroupableArgumentRequired
+			spec = spec[1:]
+		case len(spec) >= 1 && spec[0] == ';' && name == 'W':
+			return nil, ErrInvalidGetoptSpec{Spec: optstring, Reason: "`W;` is not supported"}
+		}
+		if name == ':' || name == '-' || name == '+' {
+			return nil, ErrInvalidGetoptSpec{Spec: optstring, Reason: fmt.Sprintf("invalid option name %q", name)}
+		}
+		option := newShortOption(name, optionType)
+		shorts[name] = option
+		px.AddOption(option)
+	}
+
+	// Create the long options.
+	longTypes := map[GetoptArgument]OptionType{
+		GetoptNoArgument:       OptionTypeStandaloneArgumentNone,
+		GetoptRequiredArgument: OptionTypeStandaloneArgumentRequired,
+		GetoptOptionalArgument: OptionTypeStandaloneArgumentOptional,
+	}
+	for _, longopt := range longopts {
+		optionType, found := longTypes[longopt.HasArg]
+		if !found {
+			return nil, ErrInvalidGetoptSpec{Spec: longopt.Name, Reason: fmt.Sprintf("invalid argument kind %d", longopt.HasArg)}
+		}
+		option := newLongOption(longopt.Name, optionType)
+		if option == nil {
+			return nil, ErrInvalidGetoptSpec{Spec: longopt.Name, Reason: "empty long option name"}
+		}
+		option.KeepEmptyValue = optionType == OptionTypeStandaloneArgumentOptional
+		if longopt.Short != 0 {
+			short := shorts[longopt.Short]
+			if short == nil || short.Type.argumentKind() != optionType.argumentKind() {
+				return nil, ErrInvalidGetoptSpec{
+					Spec:   longopt.Name,
+					Reason: fmt.Sprintf("no matching short option %q in the optstring", longopt.Short),
+				}
+			}
+			short.Alias, option.Alias = option, short
+		}
+		px.AddOption(option)
+	}
+
+	// Make sure the options are consistent.
+	if _, err := newConfig(px); err != nil {
+		return nil, err
+	}
+	return px, nil
+}
@@ -0,0 +1,387 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrInvalidGetoptSpec(t *testing.T) {
+	err := ErrInvalidGetoptSpec{Spec: "-ab", Reason: "returning positional arguments in order is not supported"}
+	expect := `invalid getopt spec "-ab": returning positional arguments in order is not supported`
+	assert.Equal(t, expect, err.Error())
+}
+
+func TestNewGetoptParser(t *testing.T) {
+	t.Run("option types", func(t *testing.T) {
+		px, err := NewGetoptParser("+:ab:c::", []GetoptLongOption{
+			{Name: "all", HasArg: GetoptNoArgument, Short: 'a'},
+			{Name: "file", HasArg: GetoptRequiredArgument},
+			{Name: "color", HasArg: GetoptOptionalArgument, Short: 'c'},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, px.DisablePermute)
+		assert.True(t, px.HonorPosixlyCorrect)
+		assert.Equal(t, "--", px.OptionsArgumentsSeparator)
+
+		var got []string
+		for _, option := range px.Options {
+			got = append(got, option.Prefix+option.Name+" "+option.Type.String())
+		}
+		expect := []string{
+			"-a GroupableArgumentNone",
+			"-b GroupableArgumentRequired",
+			"-c GroupableArgumentOptional",
+			"--all StandaloneArgumentNone",
+			"--file StandaloneArgumentRequired",
+			"--color StandaloneArgumentOptional",
+		}
+		assert.Equal(t, expect, got)
+		assert.Same(t, px.Options[3], px.Options[0].Alias)
+		assert.Same(t, px.Options[0], px.Options[3].Alias)
+		assert.Nil(t, px.Options[1].Alias)
+		assert.Same(t, px.Options[5], px.Options[2].Alias)
+		assert.True(t, px.Options[5].KeepEmptyValue)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		type testcase struct {
+			name      string
+			optstring string
+			longopts  []GetoptLongOption
+			expect    string
+		}
+
+		cases := []testcase{
+			{
+				name:      "in order positional arguments",
+				optstring: "-ab",
+				expect:    `invalid getopt spec "-ab": returning positional arguments in order is not supported`,
+			},
+
+			{
+				name:      "W semicolon",
+				optstring: "aW;",
+				expect:    "invalid getopt spec \"aW;\": `W;` is not supported",
+			},
+
+			{
+				name:      "invalid option name",
+				optstring: "a:::",
+				expect:    `invalid getopt spec "a:::": invalid option name ':'`,
+			},
+
+			{
+				name:     "invalid argument kind",
+				longopts: []GetoptLongOption{{Name: "file", HasArg: 3}},
+				expect:   `invalid getopt spec "file": invalid argument kind 3`,
+			},
+
+			{
+				name:     "empty long option name",
+				longopts: []GetoptLongOption{{Name: ""}},
+				expect:   `invalid getopt spec "": empty long option name`,
+			},
+
+			{
+				name:      "missing short option",
+				optstring: "a",
+				longopts:  []GetoptLongOption{{Name: "file", HasArg: GetoptRequiredArgument, Short: 'f'}},
+				expect:    `invalid getopt spec "file": no matching short option 'f' in the optstring`,
+			},
+
+			{
+				name:      "mismatching short option",
+				optstring: "f",
+				longopts:  []GetoptLongOption{{Name: "file", HasArg: GetoptRequiredArgument, Short: 'f'}},
+				expect:    `invalid getopt spec "file": no matching short option 'f' in the optstring`,
+			},
+
+			{
+				name:      "duplicate option name",
+				optstring: "aa",
+				expect:    `multiple options with "a" name`,
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				px, err := NewGetoptParser(tc.optstring, tc.longopts)
+				assert.Nil(t, px)
+				if assert.Error(t, err) {
+					assert.Equal(t, tc.expect, err.Error())
+				}
+			})
+		}
+	})
+}
+
+// getoptTestCase is a command line we parse with both glibc and [NewGetoptParser].
+type getoptTestCase struct {
+	// optstring is the getopt optstring.
+	optstring string
+
+	// posixlyCorrect indicates whether to set POSIXLY_CORRECT.
+	posixlyCorrect bool
+
+	// args contains the command line arguments.
+	args []string
+
+	// diverges explains why we expect a different result, if we do.
+	diverges string
+}
+
+// getoptTestLongOptions are the long options of all the [getoptTestCase].
+var getoptTestLongOptions = []GetoptLongOption{
+	{Name: "verbose", HasArg: GetoptNoArgument, Short: 'v'},
+	{Name: "file", HasArg: GetoptRequiredArgument, Short: 'f'},
+	{Name: "color", HasArg: GetoptOptionalArgument},
+}
+
+// newGetoptTestCases returns the corpus of command lines.
+func newGetoptTestCases() []getoptTestCase {
+	return []getoptTestCase{
+		// Groupable options
+		{optstring: "ab:c::f:v", args: []string{"-a", "-v"}},
+		{optstring: "ab:c::f:v", args: []string{"-av"}},
+		{optstring: "ab:c::f:v", args: []string{"-abfoo", "bar"}},
+		{optstring: "ab:c::f:v", args: []string{"-ab", "foo", "bar"}},
+		{optstring: "ab:c::f:v", args: []string{"-b", "-a"}},
+		{optstring: "ab:c::f:v", args: []string{"-b", "--"}},
+		{optstring: "ab:c::f:v", args: []string{"-b"}},
+		{optstring: "ab:c::f:v", args: []string{"-ab"}},
+		{optstring: "ab:c::f:v", args: []string{"-x"}},
+		{optstring: "ab:c::f:v", args: []string{"-ax"}},
+		{optstring: "ab:c::f:v", args: []string{"-a="}},
+
+		// Groupable options with an optional argument
+		{optstring: "ab:c::f:v", args: []string{"-c"}},
+		{optstring: "ab:c::f:v", args: []string{"-c", "foo"}},
+		{optstring: "ab:c::f:v", args: []string{"-cfoo"}},
+		{optstring: "ab:c::f:v", args: []string{"-acfoo"}},
+		{optstring: "ab:c::f:v", args: []string{"-cav"}},
+		{optstring: "ab:c::f:v", args: []string{"-vc", "-a"}},
+
+		// Standalone options
+		{optstring: "ab:c::f:v", args: []string{"--verbose"}},
+		{optstring: "ab:c::f:v", args: []string{"--verbose=yes"}},
+		{optstring: "ab:c::f:v", args: []string{"--file=foo", "--file", "bar"}},
+		{optstring: "ab:c::f:v", args: []string{"--file="}},
+		{optstring: "ab:c::f:v", args: []string{"--file", "--"}},
+		{optstring: "ab:c::f:v", args: []string{"--file", "-a"}},
+		{optstring: "ab:c::f:v", args: []string{"--file"}},
+		{optstring: "ab:c::f:v", args: []string{"--file=a=b"}},
+		{optstring: "ab:c::f:v", args: []string{"--nope"}},
+		{optstring: "ab:c::f:v", args: []string{"-f", "foo", "--verbose", "-v"}},
+
+		// Standalone options with an optional argument
+		{optstring: "ab:c::f:v", args: []string{"--color"}},
+		{optstring: "ab:c::f:v", args: []string{"--color="}},
+		{optstring: "ab:c::f:v", args: []string{"--color=always"}},
+		{optstring: "ab:c::f:v", args: []string{"--color", "always"}},
+
+		// Permutation
+		{optstring: "ab:c::f:v", args: []string{"x", "-a", "y", "-bz", "w"}},
+		{optstring: "ab:c::f:v", args: []string{"x", "-", "-a"}},
+		{optstring: "ab:c::f:v", args: []string{"x", "--file", "y", "z"}},
+		{optstring: "+ab:c::f:v", args: []string{"-a", "x", "-v"}},
+		{optstring: "+ab:c::f:v", args: []string{"x", "-x"}},
+		{optstring: "ab:c::f:v", posixlyCorrect: true, args: []string{"-a", "x", "-v"}},
+		{optstring: "+ab:c::f:v", posixlyCorrect: true, args: []string{"x", "--verbose"}},
+		{optstring: ":ab:c::f:v", args: []string{"x", "-a", "-b"}},
+
+		// Separator
+		{optstring: "ab:c::f:v", args: []string{"--"}},
+		{optstring: "ab:c::f:v", args: []string{"-a", "--", "-v"}},
+		{optstring: "ab:c::f:v", args: []string{"x", "--", "-v", "--", "y"}},
+		{optstring: "ab:c::f:v", args: []string{"-a", "--", "x", "--"}},
+		{optstring: "+ab:c::f:v", args: []string{"-a", "--", "-v"}},
+		{optstring: "+ab:c::f:v", args: []string{"x", "--", "-v"}},
+		{optstring: "ab:c::f:v", posixlyCorrect: true, args: []string{"x", "--", "-v"}},
+
+		// Known divergences
+		{
+			optstring: "ab:c::f:v",
+			args:      []string{"--verb"},
+			diverges:  "we do not accept abbreviations of long options",
+		},
+		{
+			optstring: "ab:c::f:v",
+			args:      []string{"--col=always"},
+			diverges:  "we do not accept abbreviations of long options",
+		},
+	}
+}
+
+// getoptCorpusEntry is the JSON serialization of a [getoptTestCase] and its glibc results.
+type getoptCorpusEntry struct {
+	Optstring      string   `json:"optstring"`
+	PosixlyCorrect bool     `json:"posixly_correct"`
+	Args           []string `json:"args"`
+	Glibc          []string `json:"glibc"`
+}
+
+// runGlibcGetopt compiles the testdata/getopt/getopt.c program and
+// uses it to compute the glibc results of the given test cases.
+func runGlibcGetopt(t *testing.T, cases []getoptTestCase) []getoptCorpusEntry {
+	program := filepath.Join(t.TempDir(), "getopt")
+	cmd := exec.Command("gcc", "-Wall", "-o", program, filepath.Join("testdata", "getopt", "getopt.c"))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("cannot compile the getopt program: %s: %s", err, output)
+	}
+
+	var longopts []string
+	for _, longopt := range getoptTestLongOptions {
+		longopts = append(longopts, fmt.Sprintf("%s:%d", longopt.Name, longopt.HasArg))
+	}
+
+	var entries []getoptCorpusEntry
+	for _, tc := range cases {
+		cmd := exec.Command(program, append([]string{tc.optstring, strings.Join(longopts, ",")}, tc.args...)...)
+		cmd.Env = []string{}
+		if tc.posixlyCorrect {
+			cmd.Env = append(cmd.Env, "POSIXLY_CORRECT=1")
+		}
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("cannot run the getopt program: %s", err)
+		}
+		var glibc []string
+		for line := range strings.Lines(string(output)) {
+			glibc = append(glibc, strings.TrimSuffix(line, "\n"))
+		}
+		entries = append(entries, getoptCorpusEntry{
+			Optstring:      tc.optstring,
+			PosixlyCorrect: tc.posixlyCorrect,
+			Args:           tc.args,
+			Glibc:          glibc,
+		})
+	}
+	return entries
+}
+
+// runGetoptParser parses the test case using [NewGetoptParser] and
+// returns the results using the format of the getopt program.
+func runGetoptParser(tc getoptTestCase) ([]string, error) {
+	px, err := NewGetoptParser(tc.optstring, getoptTestLongOptions)
+	if err != nil {
+		return nil, err
+	}
+	px.LookupEnv = func(key string) (string, bool) {
+		return "1", key == "POSIXLY_CORRECT" && tc.posixlyCorrect
+	}
+
+	values, err := px.Parse(tc.args)
+	if err != nil {
+		return []string{"error"}, nil
+	}
+
+	// Like getopt_long, we skip the separator terminating the options, which
+	// is the first one unless permutation is disabled and we have seen
+	// a positional argument, which terminates the options.
+	var (
+		output         []string
+		seenPositional bool
+		seenSeparator  bool
+	)
+	for _, value := range values {
+		switch value := value.(type) {
+		case ValueOption:
+			entry := "opt:" + value.Option.Name
+			if value.Explicit {
+				entry += "=" + value.Value
+			}
+			output = append(output, entry)
+
+		case ValuePositionalArgument:
+			seenPositional = true
+			output = append(output, "arg:"+value.Value)
+
+		case ValueOptionsArgumentsSeparator:
+			if seenSeparator || (seenPositional && px.resolveDisablePermute()) {
+				output = append(output, "arg:"+value.Separator)
+			}
+			seenSeparator = true
+		}
+	}
+	return output, nil
+}
+
+func TestNewGetoptParserGlibcCorpus(t *testing.T) {
+	cases := newGetoptTestCases()
+	path := filepath.Join("getopt", "corpus.json")
+
+	// Regenerate the corpus using glibc when requested
+	if *updateGolden {
+		data, err := json.MarshalIndent(runGlibcGetopt(t, cases), "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, path, append(data, '\n'))
+	}
+
+	// Load the corpus and make sure it is consistent with the test cases
+	data, err := os.ReadFile(filepath.Join("testdata", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []getoptCorpusEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(cases) {
+		t.Fatal("the corpus is stale: please, run `go test -update`")
+	}
+
+	for idx, tc := range cases {
+		entry := entries[idx]
+		t.Run(strings.Join(append([]string{tc.optstring}, tc.args...), " "), func(t *testing.T) {
+			if entry.Optstring != tc.optstring || entry.PosixlyCorrect != tc.posixlyCorrect ||
+				!assert.Equal(t, tc.args, entry.Args) {
+				t.Fatal("the corpus is stale: please, run `go test -update`")
+			}
+			got, err := runGetoptParser(tc)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if tc.diverges != "" {
+				assert.NotEqual(t, entry.Glibc, got, tc.diverges)
+				return
+			}
+			assert.Equal(t, entry.Glibc, got)
+		})
+	}
+}
+
+func TestNewGetoptParserRoundTrip(t *testing.T) {
+	px, err := NewGetoptParser("ab:c::f:v", getoptTestLongOptions)
+	if !assert.NoError(t, err) {
+		return
+	}
+	values, err := px.Parse([]string{"-vcauto", "-c", "--file=x", "--color=", "y"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var args []string
+	for _, value := range values {
+		args = append(args, value.Strings()...)
+	}
+	assert.Equal(t, []string{"-v", "-cauto", "-c", "--file", "x", "--color=", "y"}, args)
+
+	_, err = px.Parse([]string{"-q"})
+	var errvalue ErrUnknownOption
+	assert.True(t, errors.As(err, &errvalue))
+}
@@ -3,13 +3,15 @@ module github.com/bassosimone/flagparser
 go 1.25.5
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/bassosimone/flagscanner v0.0.0-20260426205602-a02f7a8e1306
 	github.com/bassosimone/runtimex v0.0.0-20260426205938-f859235d82e0
+	github.com/spf13/pflag v1.0.10
 	github.com/stretchr/testify v1.11.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,135 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NormalizeStyle is the style used by [Normalize].
+type NormalizeStyle int
+
+// These constants define the allowed [NormalizeStyle] values.
+const (
+	// NormalizeStyleLong prefers long option names and passes arguments
+	// to standalone options using `=`, or the [*Parser] OptionValueDelimiter,
+	// if set (e.g., `--output=FILE`).
+	//
+	// This is the style to use for logging, auditing, and cache keys.
+	NormalizeStyleLong = NormalizeStyle(iota)
+
+	// NormalizeStyleLongSeparate is like [NormalizeStyleLong] but passes
+	// required arguments as separate arguments (e.g., `--output FILE`).
+	NormalizeStyleLongSeparate
+
+	// NormalizeStyleShort prefers short option names and groups together
+	// the groupable options requiring no arguments (e.g., `-Lfs -o FILE`).
+	NormalizeStyleShort
+)
+
+// Normalize returns a canonical command line for the given values.
+//
+// The values MUST have been returned by [*Parser.Parse]. The canonical command line
+// contains the options first, followed by the positional arguments and the separator,
+// if any, in their original order. We sort options by prefix and name (preserving
+// the relative order of repeated options) and rename them according to the style,
+// using the [*Option] Alias, when the alias takes the same kind of argument.
+//
+// Parsing the canonical command line with the same [*Parser] yields values that
+// are equivalent to the original ones, except for the order of distinct options,
+// and normalizing such values yields the same canonical command line.
+//
+// Use [QuotePOSIXShell] or [QuoteWindowsCmd] to render the result as a string.
+//
+// This function panics if it encounters an invalid [OptionType].
+func Normalize(values []Value, style NormalizeStyle) []string {
+	// Separate options and other values, renaming options according to the style
+	var (
+		options []ValueOption
+		others  []Value
+	)
+	for _, value := range values {
+		switch value := value.(type) {
+		case ValueOption:
+			// Note that `-cVALUE` cannot express an explicitly empty value
+			if option := normalizeOption(value.Option, style); option.Type != OptionTypeGroupableArgumentOptional ||
+				!value.Explicit || value.Value != "" {
+				value.Option = option
+			}
+			options = append(options, value)
+		default:
+			others = append(others, value)
+		}
+	}
+
+	// Sort options; in short style, no-argument groupable options come first
+	slices.SortStableFunc(options, func(a, b ValueOption) int {
+		if style == NormalizeStyleShort {
+			if ga, gb := a.Option.Type == OptionTypeGroupableArgumentNone,
+				b.Option.Type == OptionTypeGroupableArgumentNone; ga != gb {
+				if ga {
+					return -1
+				}
+				return 1
+			}
+		}
+		return strings.Compare(a.Option.Prefix+a.Option.Name, b.Option.Prefix+b.Option.Name)
+	})
+
+	// Serialize the options
+	var output []string
+	for idx := 0; idx < len(options); idx++ {
+		value := options[idx]
+		switch {
+		case style == NormalizeStyleShort && value.Option.Type == OptionTypeGroupableArgumentNone:
+			// Merge with the following groupable options sharing the same prefix
+			group := value.Option.Prefix + value.Option.Name
+			for idx+1 < len(options) && options[idx+1].Option.Type == OptionTypeGroupableArgumentNone &&
+				options[idx+1].Option.Prefix == value.Option.Prefix {
+				idx++
+				group += options[idx].Option.Name
+			}
+			output = append(output, group)
+
+		case style == NormalizeStyleLong && value.Option.Type == OptionTypeStandaloneArgumentRequired:
+			output = append(output, value.Option.Prefix+value.Option.Name+value.delimiter()+value.Value)
+
+		default:
+			output = append(output, value.Strings()...)
+		}
+	}
+
+	// Append positional arguments and separators in their original order
+	for _, value := range others {
+		output = append(output, value.Strings()...)
+	}
+	return output
+}
+
+// normalizeOption returns the option or its alias depending on the style.
+func normalizeOption(option *Option, style NormalizeStyle) *Option {
+	alias := option.Alias
+	if alias == nil || alias.Type.argumentKind() != option.Type.argumentKind() {
+		return option
+	}
+	switch style {
+	case NormalizeStyleLong, NormalizeStyleLongSeparate:
+		if len(alias.Name) > len(option.Name) {
+			return alias
+		}
+		return option
+
+	case NormalizeStyleShort:
+		if len(alias.Name) < len(option.Name) {
+			return alias
+		}
+		return option
+
+	default:
+		panic(fmt.Sprintf("unhandled normalize style: %d", style))
+	}
+}
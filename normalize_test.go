@@ -0,0 +1,175 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNormalizeParser() *Parser {
+	px := NewParser()
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddEarlyOption('h', "help")
+	px.AddOptionWithArgumentNone('f', "fail")
+	px.AddOptionWithArgumentNone('L', "location")
+	px.AddOptionWithArgumentNone('s', "silent")
+	px.AddOptionWithArgumentNone('v', "")
+	px.AddOptionWithArgumentRequired('o', "output")
+	px.AddOptionWithArgumentRequired('H', "header")
+	px.AddOptionWithArgumentRequired(0, "url")
+	px.AddLongOptionWithArgumentOptional("compress", "gzip")
+	return px
+}
+
+func TestNormalize(t *testing.T) {
+	// Define the test case structure
+	type testcase struct {
+		args  []string                  // argument vector to parse
+		style map[NormalizeStyle]string // expected canonical command lines
+	}
+
+	cases := []testcase{
+		{
+			args: []string{"https://www.example.com/", "-fsLo", "index.html", "-vv"},
+			style: map[NormalizeStyle]string{
+				NormalizeStyleLong:         "--fail --location --output=index.html --silent -v -v https://www.example.com/",
+				NormalizeStyleLongSeparate: "--fail --location --output index.html --silent -v -v https://www.example.com/",
+				NormalizeStyleShort:        "-Lfsvv -o index.html https://www.example.com/",
+			},
+		},
+
+		{
+			args: []string{"-H", "a: b", "--header=c: d", "--compress", "--url", "x", "--", "-o", "y"},
+			style: map[NormalizeStyle]string{
+				NormalizeStyleLong:         "--compress '--header=a: b' '--header=c: d' --url=x -- -o y",
+				NormalizeStyleLongSeparate: "--compress --header 'a: b' --header 'c: d' --url x -- -o y",
+				NormalizeStyleShort:        "--compress --url x -H 'a: b' -H 'c: d' -- -o y",
+			},
+		},
+
+		{
+			args: []string{"--compress=xz", "-s", "x", "--help"},
+			style: map[NormalizeStyle]string{
+				NormalizeStyleLong:         "--help",
+				NormalizeStyleLongSeparate: "--help",
+				NormalizeStyleShort:        "-h",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		for style, expect := range tc.style {
+			t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+				// Parse and normalize the command line
+				px := newTestNormalizeParser()
+				values, err := px.Parse(tc.args)
+				if !assert.NoError(t, err) {
+					return
+				}
+				canonical := Normalize(values, style)
+				assert.Equal(t, expect, QuotePOSIXShell(canonical))
+
+				// Parse and normalize again and make sure we reach a fixed point
+				again, err := px.Parse(canonical)
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Equal(t, canonical, Normalize(again, style))
+			})
+		}
+	}
+}
+
+func TestNormalizePreservesOptionValues(t *testing.T) {
+	px := newTestNormalizeParser()
+	values, err := px.Parse([]string{"-o", "-", "--compress=", "-H", "x", "--header", "y", "--compress=bzip2"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, style := range []NormalizeStyle{NormalizeStyleLong, NormalizeStyleLongSeparate, NormalizeStyleShort} {
+		again, err := px.Parse(Normalize(values, style))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Collect the values of each option using the long name as the key
+		collect := func(values []Value) map[string][]ValueOption {
+			out := make(map[string][]ValueOption)
+			for _, value := range values {
+				value := value.(ValueOption)
+				option := normalizeOption(value.Option, NormalizeStyleLong)
+				value.Option, value.Tok = option, nil
+				out[option.Name] = append(out[option.Name], value)
+			}
+			return out
+		}
+		assert.Equal(t, collect(values), collect(again))
+	}
+}
+
+func TestNormalizeGroupableArgumentOptional(t *testing.T) {
+	px, err := NewGetoptParser("c::", []GetoptLongOption{{Name: "color", HasArg: GetoptOptionalArgument, Short: 'c'}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	values, err := px.Parse([]string{"-c", "-calways", "--color=never", "--color="})
+	if !assert.NoError(t, err) {
+		return
+	}
+	expect := map[NormalizeStyle][]string{
+		NormalizeStyleLong:  {"--color", "--color=always", "--color=never", "--color="},
+		NormalizeStyleShort: {"--color=", "-c", "-calways", "-cnever"},
+	}
+	for style, args := range expect {
+		assert.Equal(t, args, Normalize(values, style))
+	}
+}
+
+func TestNormalizeSeparateArgument(t *testing.T) {
+	px := NewParser()
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddOption(&Option{
+		DefaultValue:     "auto",
+		SeparateArgument: MatchValues("always", "never"),
+		Prefix:           "--",
+		Name:             "color",
+		Type:             OptionTypeStandaloneArgumentOptional,
+	})
+	values, err := px.Parse([]string{"always", "--color"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, style := range []NormalizeStyle{NormalizeStyleLong, NormalizeStyleLongSeparate, NormalizeStyleShort} {
+		canonical := Normalize(values, style)
+		assert.Equal(t, []string{"--color=", "always"}, canonical)
+		again, err := px.Parse(canonical)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, canonical, Normalize(again, style))
+	}
+}
+
+func Test_normalizeOption(t *testing.T) {
+	t.Run("mismatching argument kinds", func(t *testing.T) {
+		short := &Option{Prefix: "-", Name: "c", Type: OptionTypeGroupableArgumentNone}
+		long := &Option{Prefix: "--", Name: "compress", Type: OptionTypeStandaloneArgumentOptional}
+		short.Alias, long.Alias = long, short
+		assert.Same(t, short, normalizeOption(short, NormalizeStyleLong))
+		assert.Same(t, long, normalizeOption(long, NormalizeStyleShort))
+	})
+
+	t.Run("invalid style", func(t *testing.T) {
+		options := NewOptionWithArgumentNone('v', "verbose")
+		assert.Panics(t, func() {
+			normalizeOption(options[0], NormalizeStyle(-1))
+		})
+	})
+}
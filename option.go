@@ -7,12 +7,61 @@
 
 package flagparser
 
+import (
+	"fmt"
+	"slices"
+)
+
 // Option specifies the kind of option to parse.
 type Option struct {
 	// DefaultValue is the default value assigned to the option
 	// [Value] when the option argument is optional.
 	DefaultValue string
 
+	// KeepEmptyValue controls how we handle an empty argument passed to
+	// an [OptionTypeStandaloneArgumentOptional] option (e.g., `--prefix=`).
+	//
+	// When false (the default), `--prefix=` is equivalent to `--prefix`
+	// and the [Value] gets the DefaultValue. When true, we keep the empty
+	// argument, which allows users to explicitly clear a setting.
+	KeepEmptyValue bool
+
+	// SeparateArgument optionally allows an [OptionTypeStandaloneArgumentOptional]
+	// option to also take its argument from the next command line argument (e.g.,
+	// `--color always` in addition to `--color=always`).
+	//
+	// We only consume the next argument when it is a positional argument for
+	// which this function returns true. Otherwise, the option gets the
+	// DefaultValue and we parse the next argument as usual. Use [MatchValues]
+	// to only accept a fixed set of values.
+	//
+	// When nil (the default), the argument must follow the `=` sign.
+	//
+	// Because, with permutation, a positional argument accepted by this function
+	// may end up right after an option without an explicit argument, [Value.Strings]
+	// renders such an option using an empty argument (e.g., `--color=`), which
+	// cannot consume the next argument. For this reason, we reject options
+	// setting both this field and KeepEmptyValue when parsing.
+	SeparateArgument func(arg string) bool
+
+	// AllowedValues optionally contains the values that the user is allowed
+	// to explicitly pass to an option taking an argument (e.g., `gzip`, `bzip2`,
+	// and `xz` for `--compress`). When parsing, we reject any other explicit
+	// value with [ErrOptionValueNotAllowed]. We do not check the DefaultValue.
+	//
+	// When empty (the default), any value is allowed.
+	AllowedValues []string
+
+	// Validator optionally validates the values that the user explicitly
+	// passes to an option taking an argument (e.g., to check that a port
+	// is within range or that a file exists). When parsing, we wrap the
+	// error returned by this function with [ErrOptionValueInvalid]. We
+	// do not check the DefaultValue. We call this function after checking
+	// the AllowedValues, if any.
+	//
+	// When nil (the default), any value is valid.
+	Validator func(value string) error
+
 	// Prefix is the prefix to use for parsing this option (e.g., `-`)
 	Prefix string
 
@@ -21,6 +70,13 @@ type Option struct {
 
 	// Type is the option type.
 	Type OptionType
+
+	// Alias optionally links equivalent options, such as a short option and the
+	// corresponding long option (e.g., `-v` and `--verbose`). The constructors
+	// creating both a short and a long option link them to each other.
+	//
+	// We use this field to pick the preferred name when normalizing.
+	Alias *Option
 }
 
 // NewOptionWithArgumentNone creates options with no arguments using GNU
@@ -28,12 +84,12 @@ type Option struct {
 //
 // A zero short option value skips adding the short option. An empty long option
 // value skips adding the long option. If both are zero/empty, this method
-// returns a nil slice.
+// returns a nil slice. When both are present, they are each other's Alias.
 //
 // Setting invalid option names (e.g., a duplicate option name) will cause
 // no errors until you attempt to parse the command line.
 func NewOptionWithArgumentNone(shortName byte, longName string) []*Option {
-	return newOptionSlice(
+	return newAliasedOptionSlice(
 		newShortOption(shortName, OptionTypeGroupableArgumentNone),
 		newLongOption(longName, OptionTypeStandaloneArgumentNone),
 	)
@@ -48,12 +104,12 @@ func NewOptionWithArgumentNone(shortName byte, longName string) []*Option {
 //
 // A zero short option value skips adding the short option. An empty long option
 // value skips adding the long option. If both are zero/empty, this method
-// returns a nil slice.
+// returns a nil slice. When both are present, they are each other's Alias.
 //
 // Setting invalid option names (e.g., a duplicate option name) will cause
 // no errors until you attempt to parse the command line.
 func NewEarlyOption(shortName byte, longName string) []*Option {
-	return newOptionSlice(
+	return newAliasedOptionSlice(
 		newShortOption(shortName, OptionTypeEarlyArgumentNone),
 		newLongOption(longName, OptionTypeEarlyArgumentNone),
 	)
@@ -64,12 +120,12 @@ func NewEarlyOption(shortName byte, longName string) []*Option {
 //
 // A zero short option value skips adding the short option. An empty long option
 // value skips adding the long option. If both are zero/empty, this method
-// returns a nil slice.
+// returns a nil slice. When both are present, they are each other's Alias.
 //
 // Setting invalid option names (e.g., a duplicate option name) will cause
 // no errors until you attempt to parse the command line.
 func NewOptionWithArgumentRequired(shortName byte, longName string) []*Option {
-	return newOptionSlice(
+	return newAliasedOptionSlice(
 		newShortOption(shortName, OptionTypeGroupableArgumentRequired),
 		newLongOption(longName, OptionTypeStandaloneArgumentRequired),
 	)
@@ -96,6 +152,14 @@ func NewLongOptionWithArgumentOptional(longName, defaultValue string) []*Option
 	}
 }
 
+// MatchValues returns a function that returns true when its argument is one
+// of the given values. Use it to initialize the [*Option] SeparateArgument field.
+func MatchValues(values ...string) func(arg string) bool {
+	return func(arg string) bool {
+		return slices.Contains(values, arg)
+	}
+}
+
 func newShortOption(shortName byte, optionType OptionType) *Option {
 	if shortName == 0 {
 		return nil
@@ -118,6 +182,13 @@ func newLongOption(longName string, optionType OptionType) *Option {
 	}
 }
 
+func newAliasedOptionSlice(short, long *Option) []*Option {
+	if short != nil && long != nil {
+		short.Alias, long.Alias = long, short
+	}
+	return newOptionSlice(short, long)
+}
+
 func newOptionSlice(options ...*Option) []*Option {
 	var out []*Option
 	for _, option := range options {
@@ -155,6 +226,15 @@ func (ot OptionType) isGroupable() bool {
 	return (ot & optionKindGroupable) != 0
 }
 
+func (ot OptionType) isValid() bool {
+	_, found := optionTypeNames[ot]
+	return found
+}
+
+func (ot OptionType) argumentKind() OptionType {
+	return ot & (optionArgumentNone | optionArgumentRequired | optionArgumentOptional)
+}
+
 // These constants define the allowed [OptionType] values.
 const (
 	// OptionTypeEarlyArgumentNone indicates an early option requiring no arguments.
@@ -190,4 +270,69 @@ const (
 	//
 	// These options can be grouped together like in `-xvzd DIR`.
 	OptionTypeGroupableArgumentRequired = optionKindGroupable | optionArgumentRequired
+
+	// OptionTypeGroupableArgumentOptional indicates a groupable option with an optional argument.
+	//
+	// Typically used for options like `-cVALUE` (or `-c` to get the default), which
+	// correspond to `c::` in a getopt optstring. The argument, if any, is the rest
+	// of the option group (e.g., `-vcVALUE`) and never the next argument.
+	OptionTypeGroupableArgumentOptional = optionKindGroupable | optionArgumentOptional
 )
+
+// optionTypeNames maps each allowed [OptionType] value to its name.
+var optionTypeNames = map[OptionType]string{
+	OptionTypeEarlyArgumentNone:          "EarlyArgumentNone",
+	OptionTypeStandaloneArgumentNone:     "StandaloneArgumentNone",
+	OptionTypeStandaloneArgumentRequired: "StandaloneArgumentRequired",
+	OptionTypeStandaloneArgumentOptional: "StandaloneArgumentOptional",
+	OptionTypeGroupableArgumentNone:      "GroupableArgumentNone",
+	OptionTypeGroupableArgumentRequired:  "GroupableArgumentRequired",
+	OptionTypeGroupableArgumentOptional:  "GroupableArgumentOptional",
+}
+
+// String returns the name of the [OptionType] without the `OptionType`
+// prefix (e.g., `StandaloneArgumentRequired`) or `OptionType(N)` when
+// the value is not one of the allowed values.
+func (ot OptionType) String() string {
+	if name, found := optionTypeNames[ot]; found {
+		return name
+	}
+	return fmt.Sprintf("OptionType(%d)", int64(ot))
+}
+
+// MarshalText implements [encoding.TextMarshaler] using the same names
+// returned by [OptionType.String]. This method returns [ErrInvalidOptionType]
+// when the value is not one of the allowed values.
+func (ot OptionType) MarshalText() ([]byte, error) {
+	name, found := optionTypeNames[ot]
+	if !found {
+		return nil, ErrInvalidOptionType{Type: ot}
+	}
+	return []byte(name), nil
+}
+
+// ErrInvalidOptionTypeName indicates that a name does not correspond to any [OptionType].
+type ErrInvalidOptionTypeName struct {
+	// Name is the invalid name.
+	Name string
+}
+
+var _ error = ErrInvalidOptionTypeName{}
+
+// Error returns a string representation of this error.
+func (err ErrInvalidOptionTypeName) Error() string {
+	return fmt.Sprintf("invalid option type name: %q", err.Name)
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler] accepting the same names
+// returned by [OptionType.String]. This method returns [ErrInvalidOptionTypeName]
+// when the name does not correspond to any allowed value.
+func (ot *OptionType) UnmarshalText(data []byte) error {
+	for value, name := range optionTypeNames {
+		if name == string(data) {
+			*ot = value
+			return nil
+		}
+	}
+	return ErrInvalidOptionTypeName{Name: string(data)}
+}
@@ -7,6 +7,8 @@
 package flagparser
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -57,6 +59,12 @@ func TestOptionType(t *testing.T) {
 			input:       OptionTypeGroupableArgumentRequired,
 			isGroupable: true,
 		},
+
+		{
+			name:        "OptionTypeGroupableArgumentOptional",
+			input:       OptionTypeGroupableArgumentOptional,
+			isGroupable: true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -94,16 +102,21 @@ func Test_NewOptionWithArgumentNone(t *testing.T) {
 	t.Run("short and long", func(t *testing.T) {
 		options := NewOptionWithArgumentNone('v', "verbose")
 		if assert.Len(t, options, 2) {
-			assert.Equal(t, &Option{
+			short := &Option{
 				Prefix: "-",
 				Name:   "v",
 				Type:   OptionTypeGroupableArgumentNone,
-			}, options[0])
-			assert.Equal(t, &Option{
+			}
+			long := &Option{
 				Prefix: "--",
 				Name:   "verbose",
 				Type:   OptionTypeStandaloneArgumentNone,
-			}, options[1])
+			}
+			short.Alias, long.Alias = long, short
+			assert.Equal(t, short, options[0])
+			assert.Equal(t, long, options[1])
+			assert.Same(t, options[1], options[0].Alias)
+			assert.Same(t, options[0], options[1].Alias)
 		}
 	})
 
@@ -139,16 +152,21 @@ func Test_NewEarlyOption(t *testing.T) {
 	t.Run("short and long", func(t *testing.T) {
 		options := NewEarlyOption('h', "help")
 		if assert.Len(t, options, 2) {
-			assert.Equal(t, &Option{
+			short := &Option{
 				Prefix: "-",
 				Name:   "h",
 				Type:   OptionTypeEarlyArgumentNone,
-			}, options[0])
-			assert.Equal(t, &Option{
+			}
+			long := &Option{
 				Prefix: "--",
 				Name:   "help",
 				Type:   OptionTypeEarlyArgumentNone,
-			}, options[1])
+			}
+			short.Alias, long.Alias = long, short
+			assert.Equal(t, short, options[0])
+			assert.Equal(t, long, options[1])
+			assert.Same(t, options[1], options[0].Alias)
+			assert.Same(t, options[0], options[1].Alias)
 		}
 	})
 
@@ -184,16 +202,21 @@ func Test_NewOptionWithArgumentRequired(t *testing.T) {
 	t.Run("short and long", func(t *testing.T) {
 		options := NewOptionWithArgumentRequired('o', "output")
 		if assert.Len(t, options, 2) {
-			assert.Equal(t, &Option{
+			short := &Option{
 				Prefix: "-",
 				Name:   "o",
 				Type:   OptionTypeGroupableArgumentRequired,
-			}, options[0])
-			assert.Equal(t, &Option{
+			}
+			long := &Option{
 				Prefix: "--",
 				Name:   "output",
 				Type:   OptionTypeStandaloneArgumentRequired,
-			}, options[1])
+			}
+			short.Alias, long.Alias = long, short
+			assert.Equal(t, short, options[0])
+			assert.Equal(t, long, options[1])
+			assert.Same(t, options[1], options[0].Alias)
+			assert.Same(t, options[0], options[1].Alias)
 		}
 	})
 
@@ -220,3 +243,62 @@ func Test_NewLongOptionWithArgumentOptional(t *testing.T) {
 		}
 	})
 }
+
+func TestMatchValues(t *testing.T) {
+	match := MatchValues("always", "auto", "never")
+	assert.True(t, match("always"))
+	assert.True(t, match("never"))
+	assert.False(t, match("sometimes"))
+	assert.False(t, match(""))
+}
+
+func TestOptionTypeText(t *testing.T) {
+	t.Run("valid values", func(t *testing.T) {
+		for value, name := range optionTypeNames {
+			assert.True(t, value.isValid())
+			assert.Equal(t, name, value.String())
+
+			data, err := value.MarshalText()
+			assert.NoError(t, err)
+			assert.Equal(t, name, string(data))
+
+			var parsed OptionType
+			assert.NoError(t, parsed.UnmarshalText(data))
+			assert.Equal(t, value, parsed)
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, value := range []OptionType{
+			0,
+			optionKindStandalone,
+			optionKindStandalone | optionKindGroupable | optionArgumentNone,
+			OptionTypeGroupableArgumentNone | 1<<20,
+		} {
+			assert.False(t, value.isValid())
+			assert.Regexp(t, `^OptionType\(\d+\)$`, value.String())
+			data, err := value.MarshalText()
+			assert.Nil(t, data)
+			assert.Equal(t, ErrInvalidOptionType{Type: value}, err)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		parsed := OptionTypeEarlyArgumentNone
+		err := parsed.UnmarshalText([]byte("StandaloneArgumentMaybe"))
+		var errvalue ErrInvalidOptionTypeName
+		assert.True(t, errors.As(err, &errvalue))
+		assert.Equal(t, `invalid option type name: "StandaloneArgumentMaybe"`, err.Error())
+		assert.Equal(t, OptionTypeEarlyArgumentNone, parsed)
+	})
+
+	t.Run("JSON encoding", func(t *testing.T) {
+		data, err := json.Marshal(map[string]OptionType{"type": OptionTypeStandaloneArgumentOptional})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"StandaloneArgumentOptional"}`, string(data))
+
+		var parsed map[string]OptionType
+		assert.NoError(t, json.Unmarshal(data, &parsed))
+		assert.Equal(t, OptionTypeStandaloneArgumentOptional, parsed["type"])
+	})
+}
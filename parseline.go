@@ -0,0 +1,241 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import "errors"
+
+// ByteRange is the range of bytes of an argument within a line,
+// starting at Start (inclusive) and ending at End (exclusive).
+type ByteRange struct {
+	// Start is the index of the first byte.
+	Start int
+
+	// End is the index following the last byte.
+	End int
+}
+
+// LineValue is a [Value] parsed by [*Parser.ParseLine] along with
+// the byte ranges of the arguments from which we parsed it.
+type LineValue struct {
+	// Value is the parsed value.
+	Value
+
+	// Ranges contains the byte range of each argument of the value within the
+	// line, which includes the quotes, if any. Most values have a single range,
+	// while options with a separate argument (e.g., `-o FILE`) have two ranges.
+	Ranges []ByteRange
+}
+
+// LineState is the state of a line parsed by [*Parser.ParseLine].
+type LineState int
+
+const (
+	// LineComplete indicates that the line is valid.
+	LineComplete = LineState(iota)
+
+	// LineNeedsMoreInput indicates that the line is not valid yet but that
+	// typing more input may make it valid (e.g., `curl -o` lacks the argument).
+	LineNeedsMoreInput
+
+	// LineInvalid indicates that the line is not valid and that typing more
+	// input at its end would not make it valid (e.g., `curl --nonexistent URL`).
+	LineInvalid
+)
+
+// LineResult is the result of [*Parser.ParseLine].
+type LineResult struct {
+	// Args contains the arguments obtained by splitting the line.
+	Args []string
+
+	// Ranges contains the byte range of each argument within the line.
+	Ranges []ByteRange
+
+	// Values contains the values we could parse, in the order in which
+	// [*Parser.Parse] would return them. When the line is not complete,
+	// this field contains the values preceding the error.
+	Values []LineValue
+
+	// State is the state of the line.
+	State LineState
+
+	// Err is the error that prevents the line from being complete, if any.
+	Err error
+
+	// Incomplete is true when the last argument may be incomplete, because
+	// the line does not end with a space or has an unterminated quote.
+	Incomplete bool
+}
+
+// ParseLine is like [*Parser.Parse] but parses a line that the user is typing
+// (e.g., inside a REPL) and tolerates an incomplete trailing argument, returning
+// the values parsed so far along with their byte ranges within the line.
+//
+// We split the line into arguments like a POSIX shell does, without performing
+// any expansion. That is, we separate arguments using spaces, tabs, and newlines,
+// we honour single quotes and double quotes, and we use `\` to escape the
+// following byte, except that, within double quotes, `\` only escapes `"`,
+// `\`, `$`, and the backtick. An unterminated quote or a trailing `\`
+// mean that the last argument is incomplete.
+//
+// We set the [LineResult] State to [LineNeedsMoreInput] when:
+//
+//  1. the line is otherwise valid but has an unterminated quote
+//
+//  2. the last argument is an option requiring an argument (e.g., `-o`)
+//
+//  3. the line lacks positional arguments
+//
+//  4. the error refers to the last argument and the last argument is
+//     incomplete, because the user may still be typing it (e.g., `--verb`)
+//
+// and to [LineInvalid] for the other errors, including the ones that
+// [*Parser.Parse] returns for an invalid configuration.
+//
+// This method does not mutate [*Parser] and is safe to call concurrently.
+//
+// The line MUST NOT include the program name.
+func (px *Parser) ParseLine(line string) *LineResult {
+	args, ranges, unterminated := splitLine(line)
+	result := &LineResult{
+		Args:       args,
+		Ranges:     ranges,
+		Incomplete: unterminated || (line != "" && !isLineSpace(line[len(line)-1])),
+	}
+	values, err := px.parseLineArgs(args, ranges)
+	result.Values = values
+	switch {
+	case err != nil && lineNeedsMoreInput(err, len(args), result.Incomplete):
+		result.State, result.Err = LineNeedsMoreInput, err
+	case err != nil:
+		result.State, result.Err = LineInvalid, err
+	case unterminated:
+		result.State = LineNeedsMoreInput
+	}
+	return result
+}
+
+// parseLineArgs parses the arguments like [*Parser.Parse] but returns
+// the values preceding the error, if any, along with their byte ranges.
+func (px *Parser) parseLineArgs(args []string, ranges []ByteRange) ([]LineValue, error) {
+	// Create the configuration
+	cfg, err := newConfig(px)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokenize and search for early options like Parse does
+	tokens := px.scan(cfg, args)
+	if value, found := earlyParse(cfg, tokens); found {
+		return []LineValue{{Value: value, Ranges: []ByteRange{ranges[value.Token().Index()]}}}, nil
+	}
+
+	// Parse one token at a time and give each option the ranges of the
+	// arguments it consumes, including its separate argument, if any
+	var (
+		dx      = newParseDriver(cfg, tokens)
+		options []Value
+	)
+	for dx.more() && err == nil {
+		step := dx.step()
+		for idx, value := range step.options {
+			lineValue := LineValue{Value: value, Ranges: []ByteRange{ranges[value.Token().Index()]}}
+			if idx == len(step.options)-1 && step.valueOf() != nil {
+				lineValue.Ranges = append(lineValue.Ranges, ranges[step.consumed[1].Index()])
+			}
+			options = append(options, lineValue)
+		}
+		err = step.err
+	}
+
+	// Check the positional arguments, which also assigns them to the slots
+	if err == nil {
+		err = px.checkPositionals(dx.positionals.values)
+	}
+	var positionals []Value
+	for _, value := range dx.positionals.values {
+		positionals = append(positionals, LineValue{Value: value, Ranges: []ByteRange{ranges[value.Token().Index()]}})
+	}
+
+	// Optionally permute, which works because LineValue implements Value
+	var output []LineValue
+	for _, value := range permute(cfg.disablePermute(), options, positionals) {
+		output = append(output, value.(LineValue))
+	}
+	return output, err
+}
+
+// lineNeedsMoreInput returns whether typing more input may fix the given error.
+func lineNeedsMoreInput(err error, numArgs int, incomplete bool) bool {
+	var (
+		tooFew      ErrTooFewPositionalArguments
+		missing     ErrMissingPositionalArgument
+		requiresArg ErrOptionRequiresArgument
+	)
+	if errors.As(err, &tooFew) || errors.As(err, &missing) {
+		return true
+	}
+	tok := errorToken(err)
+	if tok == nil || tok.Index() != numArgs-1 {
+		return false
+	}
+	return errors.As(err, &requiresArg) || incomplete
+}
+
+// isLineSpace returns whether the given byte separates arguments.
+func isLineSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n'
+}
+
+// splitLine splits the line into arguments using the rules documented
+// by [*Parser.ParseLine] and returns the arguments, their byte ranges, and
+// whether the last argument has an unterminated quote or a trailing `\`.
+func splitLine(line string) ([]string, []ByteRange, bool) {
+	var (
+		args   []string
+		ranges []ByteRange
+	)
+	for idx := 0; idx < len(line); {
+		// Skip the spaces preceding the argument
+		if isLineSpace(line[idx]) {
+			idx++
+			continue
+		}
+
+		// Read the argument until the next unquoted space
+		var (
+			arg   []byte
+			quote byte
+			start = idx
+		)
+		for ; idx < len(line) && (quote != 0 || !isLineSpace(line[idx])); idx++ {
+			switch ch := line[idx]; {
+			case quote == '\'' && ch == '\'', quote == '"' && ch == '"':
+				quote = 0
+			case quote == '\'':
+				arg = append(arg, ch)
+			case ch == '\\' && idx+1 >= len(line):
+				return append(args, string(arg)), append(ranges, ByteRange{start, len(line)}), true
+			case ch == '\\' && (quote == 0 || isDoubleQuoteEscape(line[idx+1])):
+				idx++
+				arg = append(arg, line[idx])
+			case quote == 0 && (ch == '\'' || ch == '"'):
+				quote = ch
+			default:
+				arg = append(arg, ch)
+			}
+		}
+		args = append(args, string(arg))
+		ranges = append(ranges, ByteRange{start, idx})
+		if quote != 0 {
+			return args, ranges, true
+		}
+	}
+	return args, ranges, false
+}
+
+// isDoubleQuoteEscape returns whether `\` escapes the given byte within double quotes.
+func isDoubleQuoteEscape(ch byte) bool {
+	return ch == '"' || ch == '\\' || ch == '$' || ch == '`'
+}
@@ -0,0 +1,226 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_splitLine(t *testing.T) {
+	type testcase struct {
+		line         string
+		expectArgs   []string
+		expectRanges []ByteRange
+		expectOpen   bool
+	}
+
+	cases := []testcase{
+		{line: "", expectArgs: nil, expectRanges: nil},
+		{line: " \t\n", expectArgs: nil, expectRanges: nil},
+		{
+			line:         "-fo  FILE ",
+			expectArgs:   []string{"-fo", "FILE"},
+			expectRanges: []ByteRange{{0, 3}, {5, 9}},
+		},
+		{
+			line:         `'a b' "c \"d\" \e" f\ g`,
+			expectArgs:   []string{"a b", `c "d" \e`, "f g"},
+			expectRanges: []ByteRange{{0, 5}, {6, 18}, {19, 23}},
+		},
+		{
+			line:         `--output="a b`,
+			expectArgs:   []string{"--output=a b"},
+			expectRanges: []ByteRange{{0, 13}},
+			expectOpen:   true,
+		},
+		{
+			line:         `x 'y`,
+			expectArgs:   []string{"x", "y"},
+			expectRanges: []ByteRange{{0, 1}, {2, 4}},
+			expectOpen:   true,
+		},
+		{
+			line:         `x y\`,
+			expectArgs:   []string{"x", "y"},
+			expectRanges: []ByteRange{{0, 1}, {2, 4}},
+			expectOpen:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.line, func(t *testing.T) {
+			args, ranges, open := splitLine(tc.line)
+			assert.Equal(t, tc.expectArgs, args)
+			assert.Equal(t, tc.expectRanges, ranges)
+			assert.Equal(t, tc.expectOpen, open)
+		})
+	}
+}
+
+func TestParser_ParseLine(t *testing.T) {
+	newCurlParser := func() *Parser {
+		px := NewParser()
+		px.SetMinMaxPositionalArguments(1, math.MaxInt)
+		px.AddOptionWithArgumentNone('f', "fail")
+		px.AddOptionWithArgumentRequired('o', "output")
+		px.AddEarlyOption('h', "help")
+		return px
+	}
+
+	// lineValues flattens the values and their ranges for comparison.
+	type lineValue struct {
+		strings []string
+		ranges  []ByteRange
+	}
+	lineValues := func(values []LineValue) []lineValue {
+		output := []lineValue{}
+		for _, value := range values {
+			output = append(output, lineValue{value.Strings(), value.Ranges})
+		}
+		return output
+	}
+
+	type testcase struct {
+		name             string
+		line             string
+		expectValues     []lineValue
+		expectState      LineState
+		expectErr        string
+		expectIncomplete bool
+	}
+
+	cases := []testcase{
+		{
+			name: "complete line with permutation",
+			line: "https://x.com/ -fo 'my file' --output=FILE ",
+			expectValues: []lineValue{
+				{[]string{"-f"}, []ByteRange{{15, 18}}},
+				{[]string{"-o", "my file"}, []ByteRange{{15, 18}, {19, 28}}},
+				{[]string{"--output", "FILE"}, []ByteRange{{29, 42}}},
+				{[]string{"https://x.com/"}, []ByteRange{{0, 14}}},
+			},
+			expectState: LineComplete,
+		},
+
+		{
+			name: "complete line with incomplete last argument",
+			line: "https://x.com/",
+			expectValues: []lineValue{
+				{[]string{"https://x.com/"}, []ByteRange{{0, 14}}},
+			},
+			expectState:      LineComplete,
+			expectIncomplete: true,
+		},
+
+		{
+			name: "option requiring an argument at end of input",
+			line: "https://x.com/ -f --output ",
+			expectValues: []lineValue{
+				{[]string{"-f"}, []ByteRange{{15, 17}}},
+				{[]string{"https://x.com/"}, []ByteRange{{0, 14}}},
+			},
+			expectState: LineNeedsMoreInput,
+			expectErr:   "option requires an argument: --output",
+		},
+
+		{
+			name:         "missing positional arguments",
+			line:         "-f ",
+			expectValues: []lineValue{{[]string{"-f"}, []ByteRange{{0, 2}}}},
+			expectState:  LineNeedsMoreInput,
+			expectErr:    "too few positional arguments: expected at least 1, got 0",
+		},
+
+		{
+			name:             "option name still being typed",
+			line:             "https://x.com/ --fa",
+			expectValues:     []lineValue{{[]string{"https://x.com/"}, []ByteRange{{0, 14}}}},
+			expectState:      LineNeedsMoreInput,
+			expectErr:        "unknown option: --fa",
+			expectIncomplete: true,
+		},
+
+		{
+			name:         "unknown option followed by a space",
+			line:         "https://x.com/ --fa ",
+			expectValues: []lineValue{{[]string{"https://x.com/"}, []ByteRange{{0, 14}}}},
+			expectState:  LineInvalid,
+			expectErr:    "unknown option: --fa",
+		},
+
+		{
+			name: "unknown option within a group",
+			line: "https://x.com/ -fq ",
+			expectValues: []lineValue{
+				{[]string{"-f"}, []ByteRange{{15, 18}}},
+				{[]string{"https://x.com/"}, []ByteRange{{0, 14}}},
+			},
+			expectState: LineInvalid,
+			expectErr:   "unknown option: -q",
+		},
+
+		{
+			name:             "unknown option followed by other arguments",
+			line:             "--fa https://x.com/",
+			expectValues:     []lineValue{},
+			expectState:      LineInvalid,
+			expectErr:        "unknown option: --fa",
+			expectIncomplete: true,
+		},
+
+		{
+			name: "unterminated quote",
+			line: `https://x.com/ -o "my fi`,
+			expectValues: []lineValue{
+				{[]string{"-o", "my fi"}, []ByteRange{{15, 17}, {18, 24}}},
+				{[]string{"https://x.com/"}, []ByteRange{{0, 14}}},
+			},
+			expectState:      LineNeedsMoreInput,
+			expectIncomplete: true,
+		},
+
+		{
+			name:             "early option",
+			line:             "--nonexistent -h",
+			expectValues:     []lineValue{{[]string{"-h"}, []ByteRange{{14, 16}}}},
+			expectState:      LineComplete,
+			expectIncomplete: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := newCurlParser().ParseLine(tc.line)
+			assert.Equal(t, tc.expectValues, lineValues(result.Values))
+			assert.Equal(t, tc.expectState, result.State)
+			if tc.expectErr != "" {
+				assert.EqualError(t, result.Err, tc.expectErr)
+			} else {
+				assert.NoError(t, result.Err)
+			}
+			assert.Equal(t, tc.expectIncomplete, result.Incomplete)
+		})
+	}
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		px := newCurlParser()
+		px.AddOption(&Option{Prefix: "--", Name: "fail"})
+		result := px.ParseLine("-f")
+		assert.Equal(t, LineInvalid, result.State)
+		assert.Error(t, result.Err)
+		assert.Empty(t, result.Values)
+	})
+
+	t.Run("positional argument slots", func(t *testing.T) {
+		px := newCurlParser()
+		px.AddPositionalArgument(&PositionalArgument{Name: "URL"})
+		result := px.ParseLine("-f https://x.com/")
+		assert.Equal(t, LineComplete, result.State)
+		assert.Equal(t, "URL", result.Values[1].Value.(ValuePositionalArgument).Argument.Name)
+	})
+}
@@ -8,6 +8,8 @@ package flagparser
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
 
 	"github.com/bassosimone/flagscanner"
 	"github.com/bassosimone/runtimex"
@@ -50,14 +52,38 @@ type Parser struct {
 	// becomes unnecessary and the UX is improved.
 	DisablePermute bool
 
+	// HonorPosixlyCorrect optionally disables permuting options and arguments,
+	// as if DisablePermute was true, when the POSIXLY_CORRECT environment
+	// variable is set. This mimics glibc getopt, which disables permutation
+	// when the variable is set, even to an empty value. (The glibc getopt
+	// also disables permutation when the optstring starts with `+`, which
+	// is equivalent to setting DisablePermute to true.)
+	//
+	// When permutation is disabled, the first positional argument terminates
+	// the options and all the following arguments, including those looking
+	// like options, are positional arguments. This is also true of early
+	// options, therefore `cmd file --help` does not print the help. Unlike
+	// glibc, a `--` following the first positional argument is still a
+	// [ValueOptionsArgumentsSeparator] and counts as a positional argument.
+	HonorPosixlyCorrect bool
+
+	// LookupEnv optionally overrides the function used to read the
+	// environment when HonorPosixlyCorrect is true, which is useful
+	// for testing. When nil (the default), we use [os.LookupEnv].
+	LookupEnv func(key string) (string, bool)
+
 	// MaxPositionalArguments is the maximum number of positional
 	// arguments allowed by the parser. The default is zero, meaning
 	// that the parser won't accept more than zero positionals.
+	//
+	// We ignore this field when PositionalArguments is not empty.
 	MaxPositionalArguments int
 
 	// MinPositionalArguments is the minimum number of positional
 	// arguments allowed by the parser. The default is zero, meaning
 	// that the parser won't accept less than zero positionals.
+	//
+	// We ignore this field when PositionalArguments is not empty.
 	MinPositionalArguments int
 
 	// OptionsArgumentsSeparator is the optional separator that terminates
@@ -77,6 +103,59 @@ type Parser struct {
 	// the prefix for long options. No options will be defined so
 	// any option will be considered unknown and cause a parse error.
 	Options []*Option
+
+	// OptionValueDelimiter optionally contains the delimiter between the name
+	// of a standalone option and its value (e.g., `:` for `/out:FILE`). The
+	// default is empty, meaning that we use `=` (e.g., `--output=FILE`).
+	OptionValueDelimiter string
+
+	// CaseInsensitive optionally makes option names case insensitive (e.g.,
+	// `/OUT` is equivalent to `/out`), which is common on Windows. In such
+	// a case, option names that only differ by case are duplicates.
+	CaseInsensitive bool
+
+	// PrefixAliases optionally maps additional prefixes to the prefixes used
+	// by the options (e.g., `--` to `-` to also accept `--verbose` for `-verbose`,
+	// which is what the Go flag package does). When parsing, we will ensure that
+	// the aliases are not used by any option and that the prefixes are.
+	//
+	// We ignore this field when there are no options.
+	PrefixAliases map[string]string
+
+	// PositionalArguments optionally contains named positional argument
+	// slots (e.g., `SRC...` and `DST` for a cp-like command).
+	//
+	// When this field is not empty, we assign each parsed positional
+	// argument to a slot, validate it, and set the [ValuePositionalArgument]
+	// Argument field, ignoring MinPositionalArguments and MaxPositionalArguments.
+	//
+	// When parsing, we will ensure that slots have a name and that at
+	// most one slot is variadic. See [PositionalArgument] for details
+	// on how we assign positional arguments to slots.
+	PositionalArguments []*PositionalArgument
+
+	// Logger optionally receives structured events describing the parsing
+	// decisions at [slog.LevelDebug], which is useful to understand, e.g., why
+	// we treated an option as a positional argument. When nil (the default),
+	// we do not emit any event. See [*Parser.Parse] for the list of events.
+	//
+	// Because [*slog.Logger] is safe for concurrent use, concurrent
+	// [*Parser.Parse] calls may share the same Logger.
+	Logger *slog.Logger
+}
+
+// resolveDisablePermute returns whether permutation is disabled, taking
+// into account the HonorPosixlyCorrect field and the environment.
+func (px *Parser) resolveDisablePermute() bool {
+	if px.DisablePermute || !px.HonorPosixlyCorrect {
+		return px.DisablePermute
+	}
+	lookupEnv := px.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	_, found := lookupEnv("POSIXLY_CORRECT")
+	return found
 }
 
 // ErrTooFewPositionalArguments is returned when the number of positional
@@ -136,6 +215,114 @@ func NewParser() *Parser {
 	}
 }
 
+// NewGoParser creates a new [*Parser] following the Go flag package convention.
+//
+// Specifically, we use these settings:
+//
+//  1. command line permutation is disabled, therefore, the first
+//     positional argument terminates the options
+//
+//  2. zero positional arguments are allowed
+//
+//  3. the separator is set to `--`
+//
+//  4. the `--` prefix is an alias for the `-` prefix
+//
+//  5. no options have been defined yet
+//
+// Add standalone options using the `-` prefix (e.g., `-output`), which users
+// can also write as `--output`. Note that, unlike the Go flag package, we
+// do not allow `-flag=false` for options taking no argument.
+func NewGoParser() *Parser {
+	return &Parser{
+		DisablePermute:            true,
+		MaxPositionalArguments:    0,
+		MinPositionalArguments:    0,
+		OptionsArgumentsSeparator: "--",
+		Options:                   []*Option{},
+		PrefixAliases:             map[string]string{"--": "-"},
+	}
+}
+
+// NewPOSIXParser creates a new [*Parser] following the POSIX getopt convention.
+//
+// Specifically, we use these settings:
+//
+//  1. command line permutation is disabled, therefore, the first
+//     positional argument terminates the options
+//
+//  2. zero positional arguments are allowed
+//
+//  3. the separator is set to `--`
+//
+//  4. no options have been defined yet
+//
+// POSIX only defines short options, therefore you should only add groupable
+// options using the `-` prefix (e.g., with [*Parser.AddOptionWithArgumentNone]
+// using an empty long option name).
+func NewPOSIXParser() *Parser {
+	return &Parser{
+		DisablePermute:            true,
+		MaxPositionalArguments:    0,
+		MinPositionalArguments:    0,
+		OptionsArgumentsSeparator: "--",
+		Options:                   []*Option{},
+	}
+}
+
+// NewWindowsParser creates a new [*Parser] following the Windows convention.
+//
+// Specifically, we use these settings:
+//
+//  1. command line permutation is enabled
+//
+//  2. zero positional arguments are allowed
+//
+//  3. there is no separator
+//
+//  4. `:` separates option names and values (e.g., `/out:FILE`)
+//
+//  5. option names are case insensitive (e.g., `/OUT:FILE`)
+//
+//  6. no options have been defined yet
+//
+// Add standalone options using the `/` prefix (e.g., `/out`).
+func NewWindowsParser() *Parser {
+	return &Parser{
+		CaseInsensitive:           true,
+		DisablePermute:            false,
+		MaxPositionalArguments:    0,
+		MinPositionalArguments:    0,
+		OptionValueDelimiter:      ":",
+		OptionsArgumentsSeparator: "",
+		Options:                   []*Option{},
+	}
+}
+
+// NewDigParser creates a new [*Parser] following the dig convention.
+//
+// Specifically, we use these settings:
+//
+//  1. command line permutation is enabled
+//
+//  2. zero positional arguments are allowed
+//
+//  3. there is no separator
+//
+//  4. no options have been defined yet
+//
+// Add standalone options using the `+` prefix (e.g., `+short`) and,
+// optionally, groupable options using the `-` prefix (e.g., `-p`).
+func NewDigParser() *Parser {
+	return &Parser{
+		DisablePermute:            false,
+		MaxPositionalArguments:    0,
+		MinPositionalArguments:    0,
+		OptionsArgumentsSeparator: "",
+		Options:                   []*Option{},
+	}
+}
+
 // SetMinMaxPositionalArguments sets the minimum and maximum positional arguments.
 //
 // This method MUTATES [*Parser] and is NOT SAFE to call concurrently.
@@ -161,6 +348,20 @@ func (px *Parser) AddOption(options ...*Option) {
 	}
 }
 
+// AddPositionalArgument adds one or more named positional argument slots to the parser.
+//
+// This method MUTATES [*Parser] and is NOT SAFE to call concurrently.
+//
+// Setting invalid slots (e.g., two variadic slots) will cause
+// no errors until you attempt to parse the command line.
+func (px *Parser) AddPositionalArgument(arguments ...*PositionalArgument) {
+	for _, argument := range arguments {
+		if argument != nil {
+			px.PositionalArguments = append(px.PositionalArguments, argument)
+		}
+	}
+}
+
 // AddOptionWithArgumentNone adds a short and long option taking no argument
 // and using the `-` and `--` prefixes, which follow the GNU conventions.
 //
@@ -234,6 +435,29 @@ func (px *Parser) AddLongOptionWithArgumentOptional(longName, defaultValue strin
 
 // Parse parses the command line arguments.
 //
+// When the [*Parser] Logger is not nil and enabled at [slog.LevelDebug], we
+// emit these debug events, each with the `index` attribute containing the
+// index of the related argument:
+//
+//  1. "early option short-circuit" when we find an early option (e.g.,
+//     `--help`), with the `option` attribute containing its name
+//
+//  2. "token classified" for each argument, with the `kind` attribute containing
+//     `option`, `positional`, `separator`, or `option-as-positional`, and the
+//     `token` attribute containing the argument
+//
+//  3. "option resolved" for each option we find, with the `option` attribute
+//     containing its name and the `token` attribute containing the argument
+//
+//  4. "value consumed from next token" when an option uses the following
+//     argument as its value, with the `option` and `value` attributes
+//
+//  5. "permutation stopped" when we start treating all the following arguments as
+//     positional, with the `reason` attribute containing `separator` or `no-permute`
+//
+//  6. "option rejected" when we cannot parse an option, with
+//     the `token` and `error` attributes
+//
 // This method does not mutate [*Parser] and is safe to call concurrently.
 //
 // The args MUST NOT include the program name.
@@ -244,24 +468,15 @@ func (px *Parser) Parse(args []string) ([]Value, error) {
 		return nil, err
 	}
 
-	// Create scanner for the parser.
-	sx := &flagscanner.Scanner{
-		Separator: px.OptionsArgumentsSeparator,
-		Prefixes:  []string{},
-	}
-	for prefix := range cfg.prefixes {
-		sx.Prefixes = append(sx.Prefixes, prefix)
-	}
-
 	// Tokenize the command line arguments.
-	tokens := sx.Scan(args)
+	tokens := px.scan(cfg, args)
 
 	// Preflight the command line arguments searching for early options
 	// and return immediately when found. This algorithm allows for
 	// immediately intercepting `--help` regardless of possibly invalid
 	// options, which, in turn, improves the UX, because we can show
 	// the full help to the user rather than errors.
-	if value, found := earlyParse(px.Options, tokens, px.DisablePermute); found {
+	if value, found := earlyParse(cfg, tokens); found {
 		return []Value{value}, nil
 	}
 
@@ -280,21 +495,45 @@ func (px *Parser) Parse(args []string) ([]Value, error) {
 	// Ensure this stage has emptied the input deque.
 	runtimex.Assert(input.Empty())
 
+	// Check the positional arguments.
+	if err := px.checkPositionals(positionals.values); err != nil {
+		return nil, err
+	}
+
+	// Create the result slice by optionally permuting the entries.
+	result := permute(cfg.disablePermute(), options.values, positionals.values)
+	return result, nil
+}
+
+// scan tokenizes the command line arguments using the given config.
+func (px *Parser) scan(cfg *config, args []string) []flagscanner.Token {
+	sx := &flagscanner.Scanner{
+		Separator: px.OptionsArgumentsSeparator,
+		Prefixes:  cfg.scannerPrefixes(),
+	}
+	return sx.Scan(args)
+}
+
+// checkPositionals assigns the positional arguments to the named slots, when
+// we have them, and otherwise ensures that their number is within the limits.
+func (px *Parser) checkPositionals(positionals []Value) error {
+	// When we have named slots, assign positional arguments to them.
+	if len(px.PositionalArguments) > 0 {
+		return assignPositionalArguments(px.PositionalArguments, positionals)
+	}
+
 	// Ensure the number of positional arguments is within the limits.
-	if len(positionals.values) < px.MinPositionalArguments {
-		return nil, ErrTooFewPositionalArguments{
+	if len(positionals) < px.MinPositionalArguments {
+		return ErrTooFewPositionalArguments{
 			Min:  px.MinPositionalArguments,
-			Have: len(positionals.values),
+			Have: len(positionals),
 		}
 	}
-	if len(positionals.values) > px.MaxPositionalArguments {
-		return nil, ErrTooManyPositionalArguments{
+	if len(positionals) > px.MaxPositionalArguments {
+		return ErrTooManyPositionalArguments{
 			Max:  px.MaxPositionalArguments,
-			Have: len(positionals.values),
+			Have: len(positionals),
 		}
 	}
-
-	// Create the result slice by optionally permuting the entries.
-	result := permute(cfg.disablePermute(), options.values, positionals.values)
-	return result, nil
+	return nil
 }
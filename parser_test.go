@@ -7,9 +7,12 @@
 package flagparser
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"math"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -196,7 +199,7 @@ func TestParser_Parse(t *testing.T) {
 				}
 			},
 			expectValue: nil,
-			expectErr:   errors.New("groupable option names should be a single byte, found: &{DefaultValue: Prefix:- Name:port Type:66}"),
+			expectErr:   errors.New("groupable option names should be a single byte, found: -port (GroupableArgumentRequired)"),
 		},
 
 		{
@@ -235,7 +238,7 @@ func TestParser_Parse(t *testing.T) {
 				}
 			},
 			expectValue: nil,
-			expectErr:   errors.New("option name cannot be empty: &{DefaultValue: Prefix:-- Name: Type:34}"),
+			expectErr:   errors.New("option name cannot be empty: -- (StandaloneArgumentRequired)"),
 		},
 
 		{
@@ -252,7 +255,7 @@ func TestParser_Parse(t *testing.T) {
 				}
 			},
 			expectValue: nil,
-			expectErr:   errors.New("option prefix cannot be empty: &{DefaultValue: Prefix: Name:short Type:34}"),
+			expectErr:   errors.New("option prefix cannot be empty: short (StandaloneArgumentRequired)"),
 		},
 
 		{
@@ -277,6 +280,24 @@ func TestParser_Parse(t *testing.T) {
 			expectErr:   errors.New("prefix \"-\" is used for both standalone and groupable options"),
 		},
 
+		{
+			// Regression: invalid option types used to cause panics when parsing.
+			args: []string{"-x"},
+			newParser: func() *Parser {
+				return &Parser{
+					Options: []*Option{
+						{
+							Name:   "x",
+							Prefix: "-",
+							Type:   optionKindGroupable,
+						},
+					},
+				}
+			},
+			expectValue: nil,
+			expectErr:   errors.New("invalid option type: OptionType(64): -x"),
+		},
+
 		// Note: with DisablePermute, early options after a positional
 		// should not be recognized: the positional boundary should
 		// stop early option scanning just like it stops normal parsing.
@@ -333,3 +354,190 @@ func TestParserEmptyDefaultsToGNUStyleOptions(t *testing.T) {
 	assert.True(t, good)
 	assert.Nil(t, values)
 }
+
+func TestParserHonorPosixlyCorrect(t *testing.T) {
+	// newParser creates a GNU-style parser honoring POSIXLY_CORRECT
+	newParser := func(lookupEnv func(key string) (string, bool)) *Parser {
+		px := NewParser()
+		px.HonorPosixlyCorrect = true
+		px.LookupEnv = lookupEnv
+		px.SetMinMaxPositionalArguments(0, math.MaxInt)
+		px.AddEarlyOption('h', "help")
+		px.AddOptionWithArgumentNone('v', "verbose")
+		return px
+	}
+	posixlyCorrect := func(key string) (string, bool) {
+		return "", key == "POSIXLY_CORRECT"
+	}
+
+	type testcase struct {
+		name      string
+		lookupEnv func(key string) (string, bool)
+		args      []string
+		expect    []string
+	}
+
+	cases := []testcase{
+		{
+			name:      "without POSIXLY_CORRECT we permute",
+			lookupEnv: func(string) (string, bool) { return "", false },
+			args:      []string{"file", "-v", "--", "-x"},
+			expect:    []string{"-v", "file", "--", "-x"},
+		},
+
+		{
+			name:      "with POSIXLY_CORRECT the first positional terminates the options",
+			lookupEnv: posixlyCorrect,
+			args:      []string{"-v", "file", "-v", "--", "-x"},
+			expect:    []string{"-v", "file", "-v", "--", "-x"},
+		},
+
+		{
+			name:      "with POSIXLY_CORRECT early options before the first positional work",
+			lookupEnv: posixlyCorrect,
+			args:      []string{"-x", "--help", "file"},
+			expect:    []string{"--help"},
+		},
+
+		{
+			name:      "with POSIXLY_CORRECT early options after the first positional are positional",
+			lookupEnv: posixlyCorrect,
+			args:      []string{"file", "--help"},
+			expect:    []string{"file", "--help"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := newParser(tc.lookupEnv).Parse(tc.args)
+			assert.NoError(t, err)
+			got := []string{}
+			for _, value := range values {
+				got = append(got, value.Strings()...)
+			}
+			assert.Equal(t, tc.expect, got)
+		})
+	}
+
+	t.Run("the default uses the process environment", func(t *testing.T) {
+		t.Setenv("POSIXLY_CORRECT", "")
+		values, err := newParser(nil).Parse([]string{"file", "-v"})
+		assert.NoError(t, err)
+		if assert.Len(t, values, 2) {
+			assert.IsType(t, ValuePositionalArgument{}, values[1])
+		}
+	})
+}
+
+// recordingHandler is a [slog.Handler] recording each event as a `message key=value...` string.
+type recordingHandler struct {
+	disabled bool
+	mu       sync.Mutex
+	events   []string
+}
+
+var _ slog.Handler = &recordingHandler{}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool {
+	return !h.disabled
+}
+
+func (h *recordingHandler) Handle(_ context.Context, record slog.Record) error {
+	event := record.Message
+	record.Attrs(func(attr slog.Attr) bool {
+		event += " " + attr.String()
+		return true
+	})
+	h.mu.Lock()
+	h.events = append(h.events, event)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func TestParserLogger(t *testing.T) {
+	newParser := func(handler slog.Handler) *Parser {
+		px := NewParser()
+		px.SetMinMaxPositionalArguments(0, math.MaxInt)
+		px.AddOptionWithArgumentNone('v', "verbose")
+		px.AddOptionWithArgumentRequired('o', "output")
+		px.AddEarlyOption('h', "help")
+		px.Logger = slog.New(handler)
+		return px
+	}
+
+	t.Run("we emit events for parsing decisions", func(t *testing.T) {
+		handler := &recordingHandler{}
+		_, err := newParser(handler).Parse([]string{"-vo", "FILE", "x", "--", "-v"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"token classified index=0 kind=option token=-vo",
+			"option resolved index=0 option=-v token=-vo",
+			"option resolved index=0 option=-o token=-vo",
+			"value consumed from next token index=1 option=-o value=FILE",
+			"token classified index=2 kind=positional token=x",
+			"token classified index=3 kind=separator token=--",
+			"permutation stopped index=3 reason=separator",
+			"token classified index=4 kind=option-as-positional token=-v",
+		}, handler.events)
+	})
+
+	t.Run("we emit events when permutation is disabled", func(t *testing.T) {
+		handler := &recordingHandler{}
+		px := newParser(handler)
+		px.DisablePermute = true
+		_, err := px.Parse([]string{"x", "-v"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"token classified index=0 kind=positional token=x",
+			"permutation stopped index=0 reason=no-permute",
+			"token classified index=1 kind=option-as-positional token=-v",
+		}, handler.events)
+	})
+
+	t.Run("we emit events for early options", func(t *testing.T) {
+		handler := &recordingHandler{}
+		_, err := newParser(handler).Parse([]string{"--nonexistent", "--help"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"early option short-circuit index=1 option=--help"}, handler.events)
+	})
+
+	t.Run("we emit events for rejected options", func(t *testing.T) {
+		handler := &recordingHandler{}
+		_, err := newParser(handler).Parse([]string{"--verbose=1"})
+		assert.Error(t, err)
+		assert.Equal(t, []string{
+			"token classified index=0 kind=option token=--verbose=1",
+			"option resolved index=0 option=--verbose token=--verbose=1",
+			"option rejected index=0 token=--verbose=1 error=option requires no argument: --verbose",
+		}, handler.events)
+	})
+
+	t.Run("we emit no events when debug is disabled", func(t *testing.T) {
+		handler := &recordingHandler{disabled: true}
+		_, err := newParser(handler).Parse([]string{"-vo", "FILE", "x", "--", "-v"})
+		assert.NoError(t, err)
+		assert.Empty(t, handler.events)
+	})
+
+	t.Run("concurrent parsing can share the logger", func(t *testing.T) {
+		handler := &recordingHandler{}
+		px := newParser(handler)
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				_, err := px.Parse([]string{"-v", "x"})
+				assert.NoError(t, err)
+			})
+		}
+		wg.Wait()
+		assert.Len(t, handler.events, 8*3)
+	})
+}
@@ -0,0 +1,126 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+// Package pflagbridge converts [github.com/spf13/pflag] flag definitions
+// into [*flagparser.Option] values and applies the parsed values back to
+// the [*pflag.FlagSet], so you can keep the pflag declarations while using
+// the [flagparser] prefixes flexibility and early options.
+package pflagbridge
+
+import (
+	"math"
+
+	"github.com/bassosimone/flagparser"
+	"github.com/spf13/pflag"
+)
+
+// Options returns the options corresponding to the flags of the given [*pflag.FlagSet],
+// using the [*pflag.FlagSet] sort order. We map each flag as follows:
+//
+//  1. the long name becomes an option with the `--` prefix, which is an
+//     [flagparser.OptionTypeStandaloneArgumentOptional] option using NoOptDefVal
+//     as the DefaultValue when NoOptDefVal is set (e.g., for boolean flags) and
+//     an [flagparser.OptionTypeStandaloneArgumentRequired] option otherwise
+//
+//  2. the shorthand, if any, becomes an alias option with the `-` prefix, which is an
+//     [flagparser.OptionTypeGroupableArgumentNone] option when NoOptDefVal is set
+//     and an [flagparser.OptionTypeGroupableArgumentRequired] option otherwise
+//
+// Like pflag, we keep explicitly empty values (e.g., `--name=`). You may change
+// the options prefixes and types (e.g., to make `--help` an early option) as
+// long as you do not change their names, which [Apply] uses to find the flags.
+func Options(fs *pflag.FlagSet) []*flagparser.Option {
+	var options []*flagparser.Option
+	fs.VisitAll(func(flag *pflag.Flag) {
+		long := &flagparser.Option{
+			DefaultValue:   flag.NoOptDefVal,
+			KeepEmptyValue: true,
+			Prefix:         "--",
+			Name:           flag.Name,
+			Type:           flagparser.OptionTypeStandaloneArgumentOptional,
+		}
+		if flag.NoOptDefVal == "" {
+			long.Type = flagparser.OptionTypeStandaloneArgumentRequired
+		}
+		options = append(options, long)
+
+		if flag.Shorthand == "" {
+			return
+		}
+		short := &flagparser.Option{
+			Prefix: "-",
+			Name:   flag.Shorthand,
+			Type:   flagparser.OptionTypeGroupableArgumentNone,
+		}
+		if flag.NoOptDefVal == "" {
+			short.Type = flagparser.OptionTypeGroupableArgumentRequired
+		}
+		short.Alias, long.Alias = long, short
+		options = append(options, short)
+	})
+	return options
+}
+
+// NewParser creates a new [*flagparser.Parser] following the GNU convention
+// using the [Options] of the given [*pflag.FlagSet] and allowing any number
+// of positional arguments, like [*pflag.FlagSet] does.
+//
+// This function returns the same errors that [*flagparser.Parser.Parse]
+// returns for an invalid configuration.
+func NewParser(fs *pflag.FlagSet) (*flagparser.Parser, error) {
+	px := flagparser.NewParser()
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddOption(Options(fs)...)
+	if _, err := px.Describe(); err != nil {
+		return nil, err
+	}
+	return px, nil
+}
+
+// Apply applies the values returned by a [*flagparser.Parser] using
+// the [Options] of the given [*pflag.FlagSet] to the [*pflag.FlagSet].
+//
+// We set each option using [*pflag.FlagSet.Set], which marks the flag as
+// Changed, using NoOptDefVal as the value of options taking no argument or
+// missing the optional argument, and we return the positional arguments.
+// Because we do not call [*pflag.FlagSet.Parse], use the returned positional
+// arguments rather than the [*pflag.FlagSet] Args method.
+//
+// We return [flagparser.ErrOptionValueInvalid] when setting a flag fails (e.g.,
+// because an integer flag has a non-numeric value) and [flagparser.ErrUnknownOption]
+// when an option does not correspond to any flag.
+func Apply(fs *pflag.FlagSet, values []flagparser.Value) ([]string, error) {
+	args := []string{}
+	for _, value := range values {
+		switch value := value.(type) {
+		case flagparser.ValueOption:
+			flag := lookup(fs, value.Option.Name)
+			if flag == nil {
+				return nil, flagparser.ErrUnknownOption{Name: value.Option.Name, Prefix: value.Option.Prefix}
+			}
+			arg := value.Value
+			if !value.Explicit {
+				arg = flag.NoOptDefVal
+			}
+			if err := fs.Set(flag.Name, arg); err != nil {
+				return nil, flagparser.ErrOptionValueInvalid{Option: value.Option, Value: arg, Token: value.Tok, Err: err}
+			}
+
+		case flagparser.ValuePositionalArgument:
+			args = append(args, value.Value)
+		}
+	}
+	return args, nil
+}
+
+// lookup returns the flag with the given name or shorthand, if any.
+func lookup(fs *pflag.FlagSet, name string) *pflag.Flag {
+	if flag := fs.Lookup(name); flag != nil {
+		return flag
+	}
+	if len(name) == 1 {
+		return fs.ShorthandLookup(name)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package pflagbridge
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/bassosimone/flagparser"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFlagSet() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("x", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolP("verbose", "v", false, "")
+	fs.StringP("output", "o", "", "")
+	fs.String("color", "auto", "")
+	fs.Lookup("color").NoOptDefVal = "always"
+	fs.CountP("debug", "d", "")
+	fs.Int("retries", 3, "")
+	return fs
+}
+
+func TestOptions(t *testing.T) {
+	var got []string
+	options := Options(newTestFlagSet())
+	for _, option := range options {
+		entry := option.Prefix + option.Name + " " + option.Type.String() + " " + option.DefaultValue
+		if option.Alias != nil {
+			entry += " alias=" + option.Alias.Prefix + option.Alias.Name
+		}
+		got = append(got, entry)
+	}
+	expect := []string{
+		"--color StandaloneArgumentOptional always",
+		"--debug StandaloneArgumentOptional +1 alias=-d",
+		"-d GroupableArgumentNone  alias=--debug",
+		"--output StandaloneArgumentRequired  alias=-o",
+		"-o GroupableArgumentRequired  alias=--output",
+		"--retries StandaloneArgumentRequired ",
+		"--verbose StandaloneArgumentOptional true alias=-v",
+		"-v GroupableArgumentNone  alias=--verbose",
+	}
+	assert.Equal(t, expect, got)
+}
+
+func TestNewParser(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		px, err := NewParser(newTestFlagSet())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, px.Options, 8)
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		fs := pflag.NewFlagSet("x", pflag.ContinueOnError)
+		fs.BoolP("verbose", "v", false, "")
+		fs.Bool("v", false, "")
+		px, err := NewParser(fs)
+		assert.Nil(t, px)
+		var errvalue flagparser.ErrMultipleOptionsWithSameName
+		assert.True(t, errors.As(err, &errvalue))
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Run("options and positional arguments", func(t *testing.T) {
+		fs := newTestFlagSet()
+		px, err := NewParser(fs)
+		if !assert.NoError(t, err) {
+			return
+		}
+		values, err := px.Parse([]string{"a", "-vddo", "out.txt", "--color", "b", "--debug", "--retries=5", "--", "-v"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := Apply(fs, values)
+		assert.NoError(t, err)
+
+		verbose, _ := fs.GetBool("verbose")
+		assert.True(t, verbose)
+		output, _ := fs.GetString("output")
+		assert.Equal(t, "out.txt", output)
+		color, _ := fs.GetString("color")
+		assert.Equal(t, "always", color)
+		debug, _ := fs.GetCount("debug")
+		assert.Equal(t, 3, debug)
+		retries, _ := fs.GetInt("retries")
+		assert.Equal(t, 5, retries)
+		assert.True(t, fs.Changed("retries"))
+		assert.Equal(t, []string{"a", "b", "-v"}, args)
+		assert.False(t, fs.Parsed())
+	})
+
+	t.Run("explicit values", func(t *testing.T) {
+		fs := newTestFlagSet()
+		px, err := NewParser(fs)
+		if !assert.NoError(t, err) {
+			return
+		}
+		values, err := px.Parse([]string{"--verbose=false", "--color="})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := Apply(fs, values)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, args)
+
+		verbose, _ := fs.GetBool("verbose")
+		assert.False(t, verbose)
+		assert.True(t, fs.Changed("verbose"))
+		color, _ := fs.GetString("color")
+		assert.Equal(t, "", color)
+		assert.False(t, fs.Changed("output"))
+	})
+
+	t.Run("early options", func(t *testing.T) {
+		fs := newTestFlagSet()
+		fs.BoolP("help", "h", false, "")
+		px, err := NewParser(fs)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, option := range px.Options {
+			if option.Name == "help" || option.Name == "h" {
+				option.Type = flagparser.OptionTypeEarlyArgumentNone
+			}
+		}
+		values, err := px.Parse([]string{"--retries=x", "-h"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := Apply(fs, values)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, args)
+		help, _ := fs.GetBool("help")
+		assert.True(t, help)
+	})
+
+	t.Run("invalid flag value", func(t *testing.T) {
+		fs := newTestFlagSet()
+		px, err := NewParser(fs)
+		if !assert.NoError(t, err) {
+			return
+		}
+		values, err := px.Parse([]string{"--retries=many"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := Apply(fs, values)
+		assert.Nil(t, args)
+		var errvalue flagparser.ErrOptionValueInvalid
+		if assert.True(t, errors.As(err, &errvalue)) {
+			assert.Equal(t, "retries", errvalue.Option.Name)
+			assert.Equal(t, "many", errvalue.Value)
+		}
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		fs := newTestFlagSet()
+		px := flagparser.NewParser()
+		px.AddOptionWithArgumentNone('q', "")
+		values, err := px.Parse([]string{"-q"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		args, err := Apply(fs, values)
+		assert.Nil(t, args)
+		var errvalue flagparser.ErrUnknownOption
+		if assert.True(t, errors.As(err, &errvalue)) {
+			assert.Equal(t, "unknown option: -q", err.Error())
+		}
+	})
+}
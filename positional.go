@@ -0,0 +1,194 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"fmt"
+
+	"github.com/bassosimone/flagscanner"
+)
+
+// PositionalArgument is a named positional argument slot.
+//
+// We assign positional arguments to slots as follows:
+//
+//  1. each non-optional slot gets one argument;
+//
+//  2. optional non-variadic slots get one of the remaining
+//     arguments, if any, in the order in which they are declared;
+//
+//  3. the variadic slot, if any, gets all the remaining arguments.
+//
+// For example, with `SRC...` and `DST` (both non-optional), the last
+// argument goes to `DST` and all the other arguments go to `SRC`.
+type PositionalArgument struct {
+	// Name is the slot name (e.g., `DST`).
+	Name string
+
+	// Optional indicates that the slot may not receive any argument.
+	Optional bool
+
+	// Variadic indicates that the slot may receive more than one argument.
+	Variadic bool
+
+	// Validator optionally validates each argument assigned to this
+	// slot. When parsing, we wrap the error returned by this function
+	// with [ErrPositionalArgumentInvalid].
+	//
+	// When nil (the default), any value is valid.
+	Validator func(value string) error
+}
+
+// ErrEmptyPositionalArgumentName indicates that a positional argument slot name is empty.
+type ErrEmptyPositionalArgumentName struct {
+	// Argument is the slot with the empty name.
+	Argument *PositionalArgument
+}
+
+var _ error = ErrEmptyPositionalArgumentName{}
+
+// Error returns a string representation of this error.
+func (err ErrEmptyPositionalArgumentName) Error() string {
+	return "positional argument name cannot be empty"
+}
+
+// ErrMultipleVariadicPositionalArguments indicates that more than one
+// positional argument slot is variadic, which makes assignment ambiguous.
+type ErrMultipleVariadicPositionalArguments struct {
+	// Arguments contains the variadic slots.
+	Arguments []*PositionalArgument
+}
+
+var _ error = ErrMultipleVariadicPositionalArguments{}
+
+// Error returns a string representation of this error.
+func (err ErrMultipleVariadicPositionalArguments) Error() string {
+	return fmt.Sprintf("multiple variadic positional arguments: %d", len(err.Arguments))
+}
+
+// ErrMissingPositionalArgument indicates that no argument was
+// assigned to a non-optional positional argument slot.
+type ErrMissingPositionalArgument struct {
+	// Argument is the missing slot.
+	Argument *PositionalArgument
+}
+
+var _ error = ErrMissingPositionalArgument{}
+
+// Error returns a string representation of this error.
+func (err ErrMissingPositionalArgument) Error() string {
+	return fmt.Sprintf("missing %s", err.Argument.Name)
+}
+
+// ErrPositionalArgumentInvalid indicates that the [*PositionalArgument]
+// Validator rejected the argument assigned to the slot.
+type ErrPositionalArgumentInvalid struct {
+	// Argument is the slot to which we assigned the value.
+	Argument *PositionalArgument
+
+	// Value is the invalid value.
+	Value string
+
+	// Token is the related token.
+	Token flagscanner.Token
+
+	// Err is the error returned by the validator.
+	Err error
+}
+
+var _ error = ErrPositionalArgumentInvalid{}
+
+// Error returns a string representation of this error.
+func (err ErrPositionalArgumentInvalid) Error() string {
+	return fmt.Sprintf("invalid value for %s: %q: %s", err.Argument.Name, err.Value, err.Err.Error())
+}
+
+// Unwrap returns the error returned by the validator.
+func (err ErrPositionalArgumentInvalid) Unwrap() error {
+	return err.Err
+}
+
+// checkPositionalArguments ensures that the positional argument slots are consistent.
+func checkPositionalArguments(arguments []*PositionalArgument) error {
+	var variadic []*PositionalArgument
+	for _, argument := range arguments {
+		if len(argument.Name) <= 0 {
+			return ErrEmptyPositionalArgumentName{argument}
+		}
+		if argument.Variadic {
+			variadic = append(variadic, argument)
+		}
+	}
+	if len(variadic) > 1 {
+		return ErrMultipleVariadicPositionalArguments{variadic}
+	}
+	return nil
+}
+
+// assignPositionalArguments assigns the [ValuePositionalArgument] values
+// to the given slots, modifying the values in place, and validates them.
+//
+// We skip [ValueOptionsArgumentsSeparator] values.
+func assignPositionalArguments(arguments []*PositionalArgument, values []Value) error {
+	// Collect the indexes of the positional arguments
+	var indexes []int
+	for idx, value := range values {
+		if _, ok := value.(ValuePositionalArgument); ok {
+			indexes = append(indexes, idx)
+		}
+	}
+
+	// Give one argument to each non-optional slot
+	counts := make([]int, len(arguments))
+	extra := len(indexes)
+	for idx, argument := range arguments {
+		if !argument.Optional {
+			if extra <= 0 {
+				return ErrMissingPositionalArgument{argument}
+			}
+			counts[idx]++
+			extra--
+		}
+	}
+
+	// Give the remaining arguments to the optional and variadic slots
+	variadic := -1
+	for idx, argument := range arguments {
+		switch {
+		case argument.Variadic:
+			variadic = idx
+		case argument.Optional && extra > 0:
+			counts[idx]++
+			extra--
+		}
+	}
+	if extra > 0 {
+		if variadic < 0 {
+			return ErrTooManyPositionalArguments{Max: len(indexes) - extra, Have: len(indexes)}
+		}
+		counts[variadic] += extra
+	}
+
+	// Assign the arguments to the slots and validate them
+	for idx, argument := range arguments {
+		for ; counts[idx] > 0; counts[idx]-- {
+			value := values[indexes[0]].(ValuePositionalArgument)
+			value.Argument = argument
+			if argument.Validator != nil {
+				if err := argument.Validator(value.Value); err != nil {
+					return ErrPositionalArgumentInvalid{
+						Argument: argument,
+						Value:    value.Value,
+						Token:    value.Tok,
+						Err:      err,
+					}
+				}
+			}
+			values[indexes[0]] = value
+			indexes = indexes[1:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bassosimone/flagscanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrEmptyPositionalArgumentName(t *testing.T) {
+	err := ErrEmptyPositionalArgumentName{&PositionalArgument{Optional: true}}
+	expect := "positional argument name cannot be empty"
+	assert.Equal(t, expect, err.Error())
+}
+
+func TestErrMultipleVariadicPositionalArguments(t *testing.T) {
+	err := ErrMultipleVariadicPositionalArguments{[]*PositionalArgument{
+		{Name: "SRC", Variadic: true},
+		{Name: "DST", Variadic: true},
+	}}
+	assert.Equal(t, "multiple variadic positional arguments: 2", err.Error())
+}
+
+func TestErrMissingPositionalArgument(t *testing.T) {
+	err := ErrMissingPositionalArgument{&PositionalArgument{Name: "DST"}}
+	assert.Equal(t, "missing DST", err.Error())
+}
+
+func TestErrPositionalArgumentInvalid(t *testing.T) {
+	cause := errors.New("no such file")
+	err := ErrPositionalArgumentInvalid{
+		Argument: &PositionalArgument{Name: "SRC"},
+		Value:    "x.txt",
+		Token:    flagscanner.PositionalArgumentToken{Idx: 1, Value: "x.txt"},
+		Err:      cause,
+	}
+	assert.Equal(t, `invalid value for SRC: "x.txt": no such file`, err.Error())
+	assert.True(t, errors.Is(err, cause))
+}
+
+func Test_checkPositionalArguments(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		err := checkPositionalArguments([]*PositionalArgument{
+			{Name: "SRC", Variadic: true},
+			{Name: "DST"},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		err := checkPositionalArguments([]*PositionalArgument{{Name: ""}})
+		var errvalue ErrEmptyPositionalArgumentName
+		assert.True(t, errors.As(err, &errvalue))
+	})
+
+	t.Run("multiple variadic", func(t *testing.T) {
+		err := checkPositionalArguments([]*PositionalArgument{
+			{Name: "SRC", Variadic: true},
+			{Name: "DST", Variadic: true},
+		})
+		var errvalue ErrMultipleVariadicPositionalArguments
+		assert.True(t, errors.As(err, &errvalue))
+	})
+}
+
+func Test_assignPositionalArguments(t *testing.T) {
+	// Define the slots used by the test cases
+	src := &PositionalArgument{Name: "SRC", Variadic: true}
+	dst := &PositionalArgument{Name: "DST"}
+	mode := &PositionalArgument{Name: "MODE", Optional: true}
+	files := &PositionalArgument{Name: "FILE", Optional: true, Variadic: true}
+
+	// Define the test case structure
+	type testcase struct {
+		name      string                // name of the test case
+		arguments []*PositionalArgument // slots to use
+		input     []string              // positional arguments ("--" is the separator)
+		expect    []string              // expected slot names (empty for the separator)
+		expectErr string                // expected error, if any
+	}
+
+	cases := []testcase{
+		{
+			name:      "variadic followed by required",
+			arguments: []*PositionalArgument{src, dst},
+			input:     []string{"a", "b", "c"},
+			expect:    []string{"SRC", "SRC", "DST"},
+		},
+
+		{
+			name:      "variadic followed by missing required",
+			arguments: []*PositionalArgument{src, dst},
+			input:     []string{"a"},
+			expectErr: "missing DST",
+		},
+
+		{
+			name:      "missing variadic",
+			arguments: []*PositionalArgument{src, dst},
+			input:     []string{},
+			expectErr: "missing SRC",
+		},
+
+		{
+			name:      "separator is not assigned",
+			arguments: []*PositionalArgument{src, dst},
+			input:     []string{"a", "--", "b"},
+			expect:    []string{"SRC", "", "DST"},
+		},
+
+		{
+			name:      "optional slot receives an argument",
+			arguments: []*PositionalArgument{dst, mode, files},
+			input:     []string{"a", "b", "c", "d"},
+			expect:    []string{"DST", "MODE", "FILE", "FILE"},
+		},
+
+		{
+			name:      "optional slots receive no arguments",
+			arguments: []*PositionalArgument{dst, mode, files},
+			input:     []string{"a"},
+			expect:    []string{"DST"},
+		},
+
+		{
+			name:      "too many arguments",
+			arguments: []*PositionalArgument{dst, mode},
+			input:     []string{"a", "b", "c"},
+			expectErr: "too many positional arguments: expected at most 2, got 3",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Create the values to assign
+			var values []Value
+			for idx, entry := range tc.input {
+				if entry == "--" {
+					tok := flagscanner.OptionsArgumentsSeparatorToken{Idx: idx, Separator: entry}
+					values = append(values, ValueOptionsArgumentsSeparator{Tok: tok, Separator: entry})
+					continue
+				}
+				tok := flagscanner.PositionalArgumentToken{Idx: idx, Value: entry}
+				values = append(values, ValuePositionalArgument{Tok: tok, Value: entry})
+			}
+
+			// Assign and check for errors
+			err := assignPositionalArguments(tc.arguments, values)
+			if tc.expectErr != "" {
+				assert.EqualError(t, err, tc.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			// Compare to expectation
+			var got []string
+			for _, value := range values {
+				var name string
+				if value, ok := value.(ValuePositionalArgument); ok {
+					name = value.Argument.Name
+				}
+				got = append(got, name)
+			}
+			assert.Equal(t, tc.expect, got)
+		})
+	}
+}
+
+func TestParser_ParsePositionalArguments(t *testing.T) {
+	newParser := func() *Parser {
+		px := NewParser()
+		px.AddOptionWithArgumentNone('v', "verbose")
+		px.AddPositionalArgument(
+			nil,
+			&PositionalArgument{Name: "SRC", Variadic: true},
+			&PositionalArgument{
+				Name: "DST",
+				Validator: func(value string) error {
+					if strings.HasSuffix(value, "/") {
+						return nil
+					}
+					return errors.New("not a directory")
+				},
+			},
+		)
+		return px
+	}
+
+	t.Run("success", func(t *testing.T) {
+		px := newParser()
+		values, err := px.Parse([]string{"a.txt", "-v", "b.txt", "dir/"})
+		assert.NoError(t, err)
+		var got []string
+		for _, value := range values {
+			if value, ok := value.(ValuePositionalArgument); ok {
+				got = append(got, value.Argument.Name+"="+value.Value)
+			}
+		}
+		assert.Equal(t, []string{"SRC=a.txt", "SRC=b.txt", "DST=dir/"}, got)
+	})
+
+	t.Run("missing slot", func(t *testing.T) {
+		px := newParser()
+		_, err := px.Parse([]string{"a.txt", "-v"})
+		var errvalue ErrMissingPositionalArgument
+		if assert.True(t, errors.As(err, &errvalue)) {
+			assert.Equal(t, px.PositionalArguments[1], errvalue.Argument)
+		}
+	})
+
+	t.Run("invalid slot value", func(t *testing.T) {
+		px := newParser()
+		_, err := px.Parse([]string{"a.txt", "b.txt"})
+		assert.EqualError(t, err, `invalid value for DST: "b.txt": not a directory`)
+	})
+
+	t.Run("invalid slots", func(t *testing.T) {
+		px := newParser()
+		px.PositionalArguments[0].Name = ""
+		_, err := px.Parse([]string{"a.txt", "dir/"})
+		var errvalue ErrEmptyPositionalArgumentName
+		assert.True(t, errors.As(err, &errvalue))
+	})
+}
@@ -0,0 +1,86 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import "strings"
+
+// QuotePOSIXShell returns a string that a POSIX shell splits back into the
+// given arguments. We leave arguments consisting only of safe characters
+// unquoted and use single quotes for all the other arguments.
+func QuotePOSIXShell(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, quotePOSIXShellArg(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// posixShellSafe contains the bytes that never need quoting.
+const posixShellSafe = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_@%+=:,./-"
+
+func quotePOSIXShellArg(arg string) string {
+	if arg != "" && strings.Trim(arg, posixShellSafe) == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// QuoteWindowsCmd returns a string that, when used as a command line
+// inside cmd.exe, a program splits back into the given arguments
+// using the CommandLineToArgvW rules. To this end, we first quote
+// each argument according to such rules and then we escape the cmd.exe
+// metacharacters in the result using `^`.
+func QuoteWindowsCmd(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, escapeWindowsCmd(quoteWindowsArg(arg)))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// windowsCmdMetachars contains the bytes that cmd.exe interprets.
+const windowsCmdMetachars = `()%!^"<>&|`
+
+func escapeWindowsCmd(arg string) string {
+	var sb strings.Builder
+	for idx := 0; idx < len(arg); idx++ {
+		if strings.IndexByte(windowsCmdMetachars, arg[idx]) >= 0 {
+			sb.WriteByte('^')
+		}
+		sb.WriteByte(arg[idx])
+	}
+	return sb.String()
+}
+
+func quoteWindowsArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\v\"") {
+		return arg
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for idx := 0; ; idx++ {
+		// Count the backslashes, which are only special before a quote
+		var backslashes int
+		for ; idx < len(arg) && arg[idx] == '\\'; idx++ {
+			backslashes++
+		}
+		switch {
+		case idx >= len(arg):
+			// Double the backslashes so the closing quote is not escaped
+			sb.WriteString(strings.Repeat(`\`, 2*backslashes))
+			sb.WriteByte('"')
+			return sb.String()
+
+		case arg[idx] == '"':
+			// Double the backslashes and escape the quote
+			sb.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+			sb.WriteByte('"')
+
+		default:
+			sb.WriteString(strings.Repeat(`\`, backslashes))
+			sb.WriteByte(arg[idx])
+		}
+	}
+}
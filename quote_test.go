@@ -0,0 +1,87 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// quoteTestArgs contains tricky arguments for testing quoting.
+var quoteTestArgs = []string{
+	"",
+	"simple",
+	"--output=index.html",
+	"with space",
+	"it's",
+	`"double"`,
+	`back\slash`,
+	`trailing\`,
+	`\"`,
+	"$HOME",
+	"a&b|c",
+	"100%",
+	"~user",
+	"#comment",
+	"new\nline",
+	"(x)",
+	"^caret!",
+}
+
+func TestQuotePOSIXShell(t *testing.T) {
+	t.Run("expected output", func(t *testing.T) {
+		got := QuotePOSIXShell([]string{"", "-o", "a b", "it's", "--url=https://x.org/?q=1"})
+		assert.Equal(t, `'' -o 'a b' 'it'\''s' '--url=https://x.org/?q=1'`, got)
+	})
+
+	t.Run("round trip using the shell", func(t *testing.T) {
+		sh, err := exec.LookPath("sh")
+		if err != nil {
+			t.Skip("no POSIX shell available")
+		}
+		script := "set -- " + QuotePOSIXShell(quoteTestArgs) + `; for arg in "$@"; do printf '%s\0' "$arg"; done`
+		output, err := exec.Command(sh, "-c", script).Output()
+		if !assert.NoError(t, err) {
+			return
+		}
+		got := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+		assert.Equal(t, quoteTestArgs, got)
+	})
+}
+
+func TestQuoteWindowsCmd(t *testing.T) {
+	type testcase struct {
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{input: "", expect: `^"^"`},
+		{input: "simple", expect: `simple`},
+		{input: "/out:file.txt", expect: `/out:file.txt`},
+		{input: "with space", expect: `^"with space^"`},
+		{input: `"double"`, expect: `^"\^"double\^"^"`},
+		{input: `back\slash`, expect: `back\slash`},
+		{input: `trailing\ x\`, expect: `^"trailing\ x\\^"`},
+		{input: `\"`, expect: `^"\\\^"^"`},
+		{input: "a&b|c", expect: `a^&b^|c`},
+		{input: "100%", expect: `100^%`},
+		{input: "(x)!", expect: `^(x^)^!`},
+		{input: "<^>", expect: `^<^^^>`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			assert.Equal(t, tc.expect, QuoteWindowsCmd([]string{tc.input}))
+		})
+	}
+
+	t.Run("multiple arguments", func(t *testing.T) {
+		assert.Equal(t, `/v ^"a b^"`, QuoteWindowsCmd([]string{"/v", "a b"}))
+	})
+}
@@ -0,0 +1,458 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Document contains the metadata that [RenderMan] and [RenderMarkdown]
+// combine with a [*Parser] to render a reference page.
+type Document struct {
+	// Name is the program name (e.g., `curl`).
+	Name string
+
+	// Section is the manual section (e.g., `1`).
+	//
+	// When empty, we use section `1`.
+	Section string
+
+	// Summary is the one-line summary used in the NAME section
+	// (e.g., `transfer a URL`).
+	Summary string
+
+	// Description is the text of the DESCRIPTION section, where
+	// blank lines separate paragraphs. When empty, we omit the section.
+	Description string
+
+	// ArgumentName is the name we use for positional arguments in the
+	// SYNOPSIS when the [*Parser] has no PositionalArguments.
+	//
+	// When empty, we use `ARG`.
+	ArgumentName string
+
+	// Options optionally maps option names to their documentation. The
+	// documentation of an option also applies to its Alias.
+	Options map[string]DocumentOption
+
+	// Examples contains the examples for the EXAMPLES section. When
+	// empty, we omit the section.
+	Examples []DocumentExample
+}
+
+// DocumentOption documents an [*Option].
+type DocumentOption struct {
+	// Help is the text describing the option. Blank
+	// lines separate paragraphs.
+	Help string
+
+	// ArgumentName is the name of the option argument (e.g., `FILE`).
+	//
+	// When empty, we use `VALUE`.
+	ArgumentName string
+}
+
+// DocumentExample is an example of using the program.
+type DocumentExample struct {
+	// Help is the text describing the example.
+	Help string
+
+	// Command is the example command line.
+	Command string
+}
+
+// RenderMan writes to w a man(7) page describing the [*Parser].
+//
+// The page contains the NAME, SYNOPSIS, DESCRIPTION, OPTIONS, and EXAMPLES
+// sections. We build the SYNOPSIS and the OPTIONS from the [*Option] types,
+// prefixes, and aliases, and from the positional arguments configuration.
+//
+// This function returns the same errors that [*Parser.Parse] returns
+// for an invalid configuration and the errors occurred when writing.
+func RenderMan(w io.Writer, px *Parser, doc *Document) error {
+	entries, err := newDocEntries(px, doc)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+
+	// NAME
+	fmt.Fprintf(&out, ".TH %s %s\n", roffEscape(strings.ToUpper(doc.Name)), roffEscape(doc.section()))
+	fmt.Fprintf(&out, ".SH NAME\n%s \\- %s\n", roffEscape(doc.Name), roffEscape(doc.Summary))
+
+	// SYNOPSIS
+	fmt.Fprintf(&out, ".SH SYNOPSIS\n.B %s\n", roffEscape(doc.Name))
+	for _, item := range docSynopsis(px, doc, entries) {
+		if item.positional {
+			fmt.Fprintf(&out, "%s\n", roffEscape(item.text))
+			continue
+		}
+		fmt.Fprintf(&out, "[%s]\n", item.form.roff())
+	}
+
+	// DESCRIPTION
+	if doc.Description != "" {
+		out.WriteString(".SH DESCRIPTION\n")
+		roffParagraphs(&out, doc.Description)
+	}
+
+	// OPTIONS
+	if len(entries) > 0 {
+		out.WriteString(".SH OPTIONS\n")
+		for _, entry := range entries {
+			var forms []string
+			for _, form := range entry.forms {
+				forms = append(forms, form.roff())
+			}
+			fmt.Fprintf(&out, ".TP\n%s\n", strings.Join(forms, ", "))
+			for idx, paragraph := range entry.paragraphs() {
+				if idx > 0 {
+					out.WriteString(".IP\n")
+				}
+				fmt.Fprintf(&out, "%s\n", roffEscape(paragraph))
+			}
+		}
+	}
+
+	// EXAMPLES
+	if len(doc.Examples) > 0 {
+		out.WriteString(".SH EXAMPLES\n")
+		for _, example := range doc.Examples {
+			roffParagraphs(&out, example.Help)
+			fmt.Fprintf(&out, ".PP\n.RS\n.nf\n%s\n.fi\n.RE\n", roffEscape(example.Command))
+		}
+	}
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// RenderMarkdown writes to w a Markdown page describing the [*Parser].
+//
+// The page contains the same sections that [RenderMan] writes.
+func RenderMarkdown(w io.Writer, px *Parser, doc *Document) error {
+	entries, err := newDocEntries(px, doc)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+
+	// NAME
+	fmt.Fprintf(&out, "# %s(%s)\n\n", doc.Name, doc.section())
+	fmt.Fprintf(&out, "## NAME\n\n%s - %s\n\n", doc.Name, doc.Summary)
+
+	// SYNOPSIS
+	synopsis := []string{doc.Name}
+	for _, item := range docSynopsis(px, doc, entries) {
+		if item.positional {
+			synopsis = append(synopsis, item.text)
+			continue
+		}
+		synopsis = append(synopsis, "["+item.form.String()+"]")
+	}
+	fmt.Fprintf(&out, "## SYNOPSIS\n\n```\n%s\n```\n\n", strings.Join(synopsis, " "))
+
+	// DESCRIPTION
+	if doc.Description != "" {
+		fmt.Fprintf(&out, "## DESCRIPTION\n\n%s\n\n", strings.TrimSpace(doc.Description))
+	}
+
+	// OPTIONS
+	if len(entries) > 0 {
+		out.WriteString("## OPTIONS\n\n")
+		for _, entry := range entries {
+			var forms []string
+			for _, form := range entry.forms {
+				forms = append(forms, "`"+form.String()+"`")
+			}
+			fmt.Fprintf(&out, "### %s\n\n", strings.Join(forms, ", "))
+			for _, paragraph := range entry.paragraphs() {
+				fmt.Fprintf(&out, "%s\n\n", paragraph)
+			}
+		}
+	}
+
+	// EXAMPLES
+	if len(doc.Examples) > 0 {
+		out.WriteString("## EXAMPLES\n\n")
+		for _, example := range doc.Examples {
+			if help := strings.TrimSpace(example.Help); help != "" {
+				fmt.Fprintf(&out, "%s\n\n", help)
+			}
+			fmt.Fprintf(&out, "```sh\n%s\n```\n\n", example.Command)
+		}
+	}
+
+	// Avoid leaving a trailing blank line
+	_, err = w.Write(append(bytes.TrimRight(out.Bytes(), "\n"), '\n'))
+	return err
+}
+
+func (doc *Document) section() string {
+	if doc.Section == "" {
+		return "1"
+	}
+	return doc.Section
+}
+
+// docForm is how the user writes an option in the command line.
+type docForm struct {
+	// option is the option.
+	option *Option
+
+	// argument is the argument name or empty if the option takes no argument.
+	argument string
+
+	// delimiter separates standalone options and their argument.
+	delimiter string
+}
+
+// String returns the plain text representation of the form
+// (e.g., `-o FILE`, `--output=FILE`, `--http[=VERSION]`, or `-c[VALUE]`).
+func (form docForm) String() string {
+	name := form.option.Prefix + form.option.Name
+	switch {
+	case form.argument == "":
+		return name
+	case form.option.Type == OptionTypeStandaloneArgumentOptional:
+		return name + "[" + form.delimiter + form.argument + "]"
+	case form.option.Type == OptionTypeGroupableArgumentOptional:
+		return name + "[" + form.argument + "]"
+	case form.option.Type.isGroupable():
+		return name + " " + form.argument
+	default:
+		return name + form.delimiter + form.argument
+	}
+}
+
+// roff returns the roff representation of the form, where the option
+// is in bold and the argument name is in italic.
+func (form docForm) roff() string {
+	name := `\fB` + roffEscape(form.option.Prefix+form.option.Name) + `\fR`
+	argument := `\fI` + roffEscape(form.argument) + `\fR`
+	delimiter := roffEscape(form.delimiter)
+	switch {
+	case form.argument == "":
+		return name
+	case form.option.Type == OptionTypeStandaloneArgumentOptional:
+		return name + "[" + delimiter + argument + "]"
+	case form.option.Type == OptionTypeGroupableArgumentOptional:
+		return name + "[" + argument + "]"
+	case form.option.Type.isGroupable():
+		return name + " " + argument
+	default:
+		return name + delimiter + argument
+	}
+}
+
+// docEntry documents an option along with its alias, if any.
+type docEntry struct {
+	// forms contains the option forms in declaration order.
+	forms []docForm
+
+	// help is the help text.
+	help string
+}
+
+// paragraphs returns the paragraphs describing the entry.
+func (entry docEntry) paragraphs() []string {
+	paragraphs := docParagraphs(entry.help)
+	option := entry.forms[0].option
+	if len(option.AllowedValues) > 0 {
+		paragraphs = append(paragraphs, "Allowed values: "+strings.Join(option.AllowedValues, ", ")+".")
+	}
+	if option.Type.argumentKind() == optionArgumentOptional && option.DefaultValue != "" {
+		paragraphs = append(paragraphs, "Default value: "+option.DefaultValue+".")
+	}
+	return paragraphs
+}
+
+// newDocEntries validates the [*Parser] and returns the entries to document.
+func newDocEntries(px *Parser, doc *Document) ([]docEntry, error) {
+	cfg, err := newConfig(px)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		entries []docEntry
+		seen    = make(map[*Option]bool)
+	)
+	for _, option := range px.Options {
+		if seen[option] {
+			continue
+		}
+		options := []*Option{option}
+		if option.Alias != nil && !seen[option.Alias] && slices.Contains(px.Options, option.Alias) {
+			options = append(options, option.Alias)
+		}
+		var entry docEntry
+		for _, option := range options {
+			seen[option] = true
+			if odoc, found := doc.Options[option.Name]; found && entry.help == "" {
+				entry.help = odoc.Help
+			}
+		}
+		for _, option := range options {
+			entry.forms = append(entry.forms, docForm{
+				option:    option,
+				argument:  doc.argumentName(options, option),
+				delimiter: cfg.optionValueDelimiter(),
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// argumentName returns the argument name of the option in the entry.
+func (doc *Document) argumentName(entry []*Option, option *Option) string {
+	if option.Type.argumentKind() == optionArgumentNone {
+		return ""
+	}
+	for _, candidate := range entry {
+		if odoc := doc.Options[candidate.Name]; odoc.ArgumentName != "" {
+			return odoc.ArgumentName
+		}
+	}
+	return "VALUE"
+}
+
+// docSynopsisItem is an item of the SYNOPSIS.
+type docSynopsisItem struct {
+	// form is the option form, when this is an option.
+	form docForm
+
+	// positional indicates that this item describes positional arguments.
+	positional bool
+
+	// text is the text describing positional arguments.
+	text string
+}
+
+// docSynopsis returns the SYNOPSIS items. We merge the groupable options taking
+// no argument and sharing the same prefix (e.g., `[-fLs]`), and otherwise use
+// the first declared form of each entry.
+func docSynopsis(px *Parser, doc *Document, entries []docEntry) []docSynopsisItem {
+	var (
+		groups   = make(map[string]*Option)
+		items    []docSynopsisItem
+		prefixes []string
+	)
+	for _, entry := range entries {
+		idx := slices.IndexFunc(entry.forms, func(form docForm) bool {
+			return form.option.Type == OptionTypeGroupableArgumentNone
+		})
+		if idx < 0 {
+			items = append(items, docSynopsisItem{form: entry.forms[0]})
+			continue
+		}
+		option := entry.forms[idx].option
+		group := groups[option.Prefix]
+		if group == nil {
+			group = &Option{Prefix: option.Prefix, Type: OptionTypeGroupableArgumentNone}
+			groups[option.Prefix] = group
+			prefixes = append(prefixes, option.Prefix)
+		}
+		group.Name += option.Name
+	}
+
+	// Put the groups first, with their names sorted
+	var result []docSynopsisItem
+	for _, prefix := range prefixes {
+		group := groups[prefix]
+		names := []byte(group.Name)
+		slices.Sort(names)
+		group.Name = string(names)
+		result = append(result, docSynopsisItem{form: docForm{option: group}})
+	}
+	result = append(result, items...)
+
+	// Add the positional arguments
+	for _, text := range docPositionalArguments(px, doc) {
+		result = append(result, docSynopsisItem{positional: true, text: text})
+	}
+	return result
+}
+
+// docPositionalArguments describes the positional arguments.
+func docPositionalArguments(px *Parser, doc *Document) []string {
+	var out []string
+
+	// Named slots take precedence over the minimum and maximum
+	if len(px.PositionalArguments) > 0 {
+		for _, argument := range px.PositionalArguments {
+			text := argument.Name
+			if argument.Variadic {
+				text += "..."
+			}
+			if argument.Optional {
+				text = "[" + text + "]"
+			}
+			out = append(out, text)
+		}
+		return out
+	}
+
+	name := doc.ArgumentName
+	if name == "" {
+		name = "ARG"
+	}
+	minArgs, maxArgs := max(px.MinPositionalArguments, 0), px.MaxPositionalArguments
+	switch {
+	case maxArgs == math.MaxInt && minArgs <= 0:
+		out = append(out, "["+name+"...]")
+	case maxArgs == math.MaxInt:
+		for range minArgs - 1 {
+			out = append(out, name)
+		}
+		out = append(out, name+"...")
+	default:
+		for range minArgs {
+			out = append(out, name)
+		}
+		for range maxArgs - minArgs {
+			out = append(out, "["+name+"]")
+		}
+	}
+	return out
+}
+
+// docParagraphs splits text into paragraphs separated by blank lines.
+func docParagraphs(text string) []string {
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs
+}
+
+// roffParagraphs writes the paragraphs in text using the `.PP` macro.
+func roffParagraphs(out *bytes.Buffer, text string) {
+	for _, paragraph := range docParagraphs(text) {
+		fmt.Fprintf(out, ".PP\n%s\n", roffEscape(paragraph))
+	}
+}
+
+// roffEscape escapes text for roff. We escape backslashes and hyphens (which
+// roff would otherwise render as typographic hyphens, breaking copy and paste
+// of options) and lines starting with a control character.
+func roffEscape(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	lines := strings.Split(text, "\n")
+	for idx, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[idx] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,206 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// updateGolden controls whether to update the golden files in testdata.
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// renderTestCase is a parser along with the document we render.
+type renderTestCase struct {
+	name   string
+	parser *Parser
+	doc    *Document
+}
+
+// newRenderTestCases returns the GNU, dig, Go, and Windows style test cases.
+func newRenderTestCases() []renderTestCase {
+	// Define a curl-like parser following the GNU conventions
+	curl := NewParser()
+	curl.SetMinMaxPositionalArguments(1, math.MaxInt)
+	curl.AddEarlyOption('h', "help")
+	curl.AddOptionWithArgumentNone('f', "fail")
+	curl.AddOptionWithArgumentNone('L', "location")
+	curl.AddOptionWithArgumentRequired('o', "output")
+	curl.AddOptionWithArgumentNone('s', "silent")
+	curl.AddOption(&Option{
+		DefaultValue:  "1.1",
+		AllowedValues: []string{"1.0", "1.1", "2"},
+		Prefix:        "--",
+		Name:          "http",
+		Type:          OptionTypeStandaloneArgumentOptional,
+	})
+	curlDoc := &Document{
+		Name:         "curl",
+		Summary:      "transfer a URL",
+		Description:  "Transfers data from or to a server.\n\nThe URL syntax is protocol-dependent.",
+		ArgumentName: "URL",
+		Options: map[string]DocumentOption{
+			"help":     {Help: "Show the help and exit."},
+			"fail":     {Help: "Fail silently on server errors."},
+			"location": {Help: "Follow redirects."},
+			"o":        {Help: "Write the output to FILE instead of stdout.", ArgumentName: "FILE"},
+			"silent":   {Help: "Do not show progress.\n\nAlso hides errors."},
+			"http":     {Help: "Use the given HTTP version.", ArgumentName: "VERSION"},
+		},
+		Examples: []DocumentExample{
+			{Help: "Save a page:", Command: "curl -fsLo index.html https://www.example.com/"},
+			{Help: "Use HTTP/2:", Command: "curl --http=2 https://www.example.com/"},
+		},
+	}
+
+	// Define a dig-like parser mixing `-` and `+` prefixes
+	dig := &Parser{
+		MinPositionalArguments: 1,
+		MaxPositionalArguments: 4,
+		Options: []*Option{
+			{Name: "p", Prefix: "-", Type: OptionTypeGroupableArgumentRequired},
+			{Name: "short", Prefix: "+", Type: OptionTypeStandaloneArgumentNone},
+			{DefaultValue: "1024", Name: "bufsize", Prefix: "+", Type: OptionTypeStandaloneArgumentOptional},
+		},
+	}
+	digDoc := &Document{
+		Name:    "dig",
+		Summary: "DNS lookup utility",
+		Options: map[string]DocumentOption{
+			"p":       {Help: "Send the query to PORT.", ArgumentName: "PORT"},
+			"short":   {Help: "Provide a terse answer."},
+			"bufsize": {Help: "Set the EDNS0 buffer size.", ArgumentName: "B"},
+		},
+		Examples: []DocumentExample{
+			{Command: "dig @8.8.8.8 -p53 +short A example.com"},
+		},
+	}
+
+	// Define a parser following the Go flag package conventions
+	gox := &Parser{
+		MaxPositionalArguments:    math.MaxInt,
+		OptionsArgumentsSeparator: "--",
+		Options: []*Option{
+			{Name: "h", Prefix: "-", Type: OptionTypeEarlyArgumentNone},
+			{Name: "o", Prefix: "-", Type: OptionTypeStandaloneArgumentRequired},
+			{Name: "race", Prefix: "-", Type: OptionTypeStandaloneArgumentNone},
+			{Name: "v", Prefix: "-", Type: OptionTypeStandaloneArgumentNone},
+		},
+	}
+	goDoc := &Document{
+		Name:         "gobuild",
+		Summary:      "compile packages",
+		ArgumentName: "PACKAGE",
+		Options: map[string]DocumentOption{
+			"h":    {Help: "Show the help and exit."},
+			"o":    {Help: "Write the executable to FILE.", ArgumentName: "FILE"},
+			"race": {Help: "Enable data race detection."},
+			"v":    {Help: "Print the names of packages as they are compiled."},
+		},
+	}
+
+	// Define a parser following the Windows conventions
+	windows := &Parser{
+		CaseInsensitive:      true,
+		OptionValueDelimiter: ":",
+		PositionalArguments: []*PositionalArgument{
+			{Name: "SOURCE", Variadic: true},
+			{Name: "DESTINATION", Optional: true},
+		},
+		Options: []*Option{
+			{Name: "?", Prefix: "/", Type: OptionTypeEarlyArgumentNone},
+			{Name: "out", Prefix: "/", Type: OptionTypeStandaloneArgumentRequired},
+			{Name: "y", Prefix: "/", Type: OptionTypeStandaloneArgumentNone},
+		},
+	}
+	windowsDoc := &Document{
+		Name:    "xcopy",
+		Summary: "copy files",
+		Options: map[string]DocumentOption{
+			"?":   {Help: "Show the help and exit."},
+			"out": {Help: "Write the log to FILE.", ArgumentName: "FILE"},
+			"y":   {Help: "Overwrite without asking.\n.Lines starting with a dot are escaped."},
+		},
+	}
+
+	return []renderTestCase{
+		{name: "curl", parser: curl, doc: curlDoc},
+		{name: "dig", parser: dig, doc: digDoc},
+		{name: "gobuild", parser: gox, doc: goDoc},
+		{name: "xcopy", parser: windows, doc: windowsDoc},
+	}
+}
+
+// checkGolden compares the data with the given golden file, updating the file when requested.
+func checkGolden(t *testing.T, name string, data []byte) {
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expect), string(data))
+}
+
+func TestRenderMan(t *testing.T) {
+	for _, tc := range newRenderTestCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.NoError(t, RenderMan(&out, tc.parser, tc.doc))
+			checkGolden(t, tc.name+"."+tc.doc.section(), out.Bytes())
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	for _, tc := range newRenderTestCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.NoError(t, RenderMarkdown(&out, tc.parser, tc.doc))
+			checkGolden(t, tc.name+".md", out.Bytes())
+		})
+	}
+}
+
+func TestRenderInvalidParser(t *testing.T) {
+	px := &Parser{Options: []*Option{{Prefix: "-", Name: "xy", Type: OptionTypeGroupableArgumentNone}}}
+	doc := &Document{Name: "x"}
+
+	var out bytes.Buffer
+	var errvalue ErrTooLongGroupableOptionName
+	assert.True(t, errors.As(RenderMan(&out, px, doc), &errvalue))
+	assert.True(t, errors.As(RenderMarkdown(&out, px, doc), &errvalue))
+	assert.Empty(t, out.Bytes())
+}
+
+func Test_docPositionalArguments(t *testing.T) {
+	type testcase struct {
+		minArgs, maxArgs int
+		expect           []string
+	}
+
+	cases := []testcase{
+		{minArgs: 0, maxArgs: 0, expect: nil},
+		{minArgs: 0, maxArgs: math.MaxInt, expect: []string{"[ARG...]"}},
+		{minArgs: 2, maxArgs: math.MaxInt, expect: []string{"ARG", "ARG..."}},
+		{minArgs: 1, maxArgs: 3, expect: []string{"ARG", "[ARG]", "[ARG]"}},
+	}
+
+	for _, tc := range cases {
+		px := &Parser{MinPositionalArguments: tc.minArgs, MaxPositionalArguments: tc.maxArgs}
+		assert.Equal(t, tc.expect, docPositionalArguments(px, &Document{}))
+	}
+}
@@ -0,0 +1,279 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Source is the source of a [ResolvedValue].
+type Source int
+
+const (
+	// SourceDefault indicates the [*Option] DefaultValue.
+	SourceDefault = Source(iota)
+
+	// SourceFile indicates a configuration file.
+	SourceFile
+
+	// SourceEnv indicates an environment variable.
+	SourceEnv
+
+	// SourceArgv indicates the command line arguments.
+	SourceArgv
+)
+
+// sourceNames maps each [Source] to its name.
+var sourceNames = map[Source]string{
+	SourceDefault: "default",
+	SourceFile:    "file",
+	SourceEnv:     "env",
+	SourceArgv:    "argv",
+}
+
+// String returns the name of the source (e.g., `argv`).
+func (src Source) String() string {
+	if name, found := sourceNames[src]; found {
+		return name
+	}
+	return fmt.Sprintf("Source(%d)", int(src))
+}
+
+// Provenance describes where a [ResolvedValue] comes from.
+type Provenance struct {
+	// Source is the source of the value.
+	Source Source
+
+	// Key is the option name or the environment variable name,
+	// when the Source is [SourceFile] or [SourceEnv].
+	Key string
+
+	// Line is the 1-based line number within the configuration
+	// file, when the Source is [SourceFile].
+	Line int
+
+	// TokenIndex is the index of the token within the command
+	// line arguments, when the Source is [SourceArgv].
+	TokenIndex int
+}
+
+// String returns a string representation of the provenance (e.g., `default`,
+// `file:output:4`, `env:CURL_OUTPUT`, or `argv[3]`).
+func (p Provenance) String() string {
+	switch p.Source {
+	case SourceFile:
+		return fmt.Sprintf("%s:%s:%d", p.Source, p.Key, p.Line)
+	case SourceEnv:
+		return p.Source.String() + ":" + p.Key
+	case SourceArgv:
+		return fmt.Sprintf("%s[%d]", p.Source, p.TokenIndex)
+	default:
+		return p.Source.String()
+	}
+}
+
+// ResolvedValue is a value of a [Setting] along with its provenance.
+type ResolvedValue struct {
+	// Value is the value.
+	Value string
+
+	// Provenance describes where the value comes from.
+	Provenance Provenance
+}
+
+// MergePolicy defines how a [*Resolver] merges the values of an option.
+type MergePolicy int
+
+const (
+	// MergeOverride indicates that the value from the source with the highest
+	// priority overrides the other values and that, within the same source,
+	// the last value overrides the previous ones.
+	MergeOverride = MergePolicy(iota)
+
+	// MergeAppend indicates that we keep all the values from the configuration
+	// file, the environment variables, and the command line arguments, in this order.
+	MergeAppend
+)
+
+// Setting is the merged view of an option and its alias, if any.
+type Setting struct {
+	// Option is the option whose name is the longest between
+	// the option and its alias (e.g., `--output` for `-o`).
+	Option *Option
+
+	// Policy is the policy we used to merge the values.
+	Policy MergePolicy
+
+	// Values contains the merged values, which are never empty, because we
+	// use the [*Option] DefaultValue when no other source sets the option.
+	//
+	// With [MergeOverride], this field contains a single value.
+	Values []ResolvedValue
+}
+
+// Value returns the last value of the setting.
+func (s Setting) Value() ResolvedValue {
+	return s.Values[len(s.Values)-1]
+}
+
+// Resolver merges the option values from several sources, which are,
+// in order of increasing priority: the [*Option] DefaultValue, a
+// configuration file, the environment variables, and the command line.
+type Resolver struct {
+	// Parser is the MANDATORY parser whose options we resolve.
+	Parser *Parser
+
+	// File optionally contains the values read from a configuration
+	// file using [*Parser.ParseConfig] with the same Parser.
+	File []Value
+
+	// EnvKey optionally returns the name of the environment variable
+	// for the given option (e.g., `CURL_OUTPUT` for `--output`). When
+	// this field is nil, we do not read environment variables.
+	//
+	// See [EnvKeyWithPrefix] for a ready-to-use implementation.
+	EnvKey func(option *Option) string
+
+	// LookupEnv optionally contains the function to lookup environment
+	// variables. The default is to use [os.LookupEnv].
+	LookupEnv func(key string) (string, bool)
+
+	// Merge optionally returns the merge policy for the given option. The
+	// default is to use [MergeOverride] for all options.
+	Merge func(option *Option) MergePolicy
+}
+
+// EnvKeyWithPrefix returns a function for the [*Resolver] EnvKey field that
+// maps an option name to an environment variable name by converting it to
+// uppercase, replacing `-` with `_`, and adding the given prefix (e.g.,
+// `CURL_OUTPUT` for `--output` with the `CURL_` prefix).
+func EnvKeyWithPrefix(prefix string) func(option *Option) string {
+	return func(option *Option) string {
+		return prefix + strings.ToUpper(strings.ReplaceAll(option.Name, "-", "_"))
+	}
+}
+
+// Resolve merges the values parsed by the [*Resolver] Parser with the other
+// sources and returns a [Setting] for each option, in declaration order, except
+// for early options (e.g., `--help`), which are actions rather than settings.
+//
+// When an option takes no argument, its value is `true` when the option is set.
+// Like in configuration files, an environment variable sets such an option when
+// its value is `true` and does not set it when its value is `false`.
+//
+// We ignore the values of options not belonging to the [*Resolver] Parser.
+//
+// We return the same errors that [*Parser.Parse] returns when a value from the
+// environment variables is not valid (e.g., [ErrOptionValueNotAllowed] or
+// [ErrOptionRequiresNoArgument]) or when the [*Parser] is not valid. Note that [*Parser.ParseConfig] and [*Parser.Parse]
+// already validate the values from the configuration file and the command line.
+func (rx *Resolver) Resolve(values []Value) ([]Setting, error) {
+	if _, err := newConfig(rx.Parser); err != nil {
+		return nil, err
+	}
+
+	// Group the options and their aliases into settings
+	var (
+		settings []*Setting
+		index    = make(map[*Option]*Setting)
+	)
+	for _, option := range rx.Parser.Options {
+		if index[option] != nil || option.Type.isEarly() {
+			continue
+		}
+		setting := &Setting{Option: option}
+		if alias := option.Alias; alias != nil {
+			if len(alias.Name) > len(option.Name) {
+				setting.Option = alias
+			}
+			index[alias] = setting
+		}
+		if rx.Merge != nil {
+			setting.Policy = rx.Merge(setting.Option)
+		}
+		index[option] = setting
+		settings = append(settings, setting)
+	}
+
+	// Collect the values from the configuration file
+	resolveValues(index, SourceFile, rx.File)
+
+	// Collect the values from the environment variables
+	if rx.EnvKey != nil {
+		lookupEnv := rx.LookupEnv
+		if lookupEnv == nil {
+			lookupEnv = os.LookupEnv
+		}
+		for _, setting := range settings {
+			key := rx.EnvKey(setting.Option)
+			envValue, found := lookupEnv(key)
+			if !found {
+				continue
+			}
+			if setting.Option.Type.argumentKind() == optionArgumentNone {
+				set, err := parseNoArgumentValue(setting.Option, nil, envValue)
+				if err != nil {
+					return nil, err
+				}
+				if !set {
+					continue
+				}
+				envValue = "true"
+			}
+			if err := validateOptionValue(ValueOption{Option: setting.Option, Value: envValue, Explicit: true}); err != nil {
+				return nil, err
+			}
+			setting.add(ResolvedValue{Value: envValue, Provenance: Provenance{Source: SourceEnv, Key: key}})
+		}
+	}
+
+	// Collect the values from the command line
+	resolveValues(index, SourceArgv, values)
+
+	// Fallback to the default values and return
+	output := make([]Setting, 0, len(settings))
+	for _, setting := range settings {
+		if len(setting.Values) <= 0 {
+			value := ResolvedValue{Value: setting.Option.DefaultValue, Provenance: Provenance{Source: SourceDefault}}
+			setting.Values = append(setting.Values, value)
+		}
+		output = append(output, *setting)
+	}
+	return output, nil
+}
+
+// resolveValues adds the already validated values of the options
+// belonging to the settings index to the corresponding settings.
+func resolveValues(index map[*Option]*Setting, source Source, values []Value) {
+	for _, value := range values {
+		if value, ok := value.(ValueOption); ok && index[value.Option] != nil {
+			resolved := ResolvedValue{Value: value.Value, Provenance: Provenance{Source: source}}
+			if value.Option.Type.argumentKind() == optionArgumentNone {
+				resolved.Value = "true"
+			}
+			switch tok := value.Tok.(type) {
+			case ConfigFileToken:
+				resolved.Provenance.Key = value.Option.Name
+				resolved.Provenance.Line = tok.Line
+			case nil:
+				// nothing
+			default:
+				resolved.Provenance.TokenIndex = tok.Index()
+			}
+			index[value.Option].add(resolved)
+		}
+	}
+}
+
+// add merges the given value into the setting according to the policy.
+func (s *Setting) add(value ResolvedValue) {
+	if s.Policy == MergeAppend {
+		s.Values = append(s.Values, value)
+		return
+	}
+	s.Values = []ResolvedValue{value}
+}
@@ -0,0 +1,244 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	assert.Equal(t, "default", SourceDefault.String())
+	assert.Equal(t, "file", SourceFile.String())
+	assert.Equal(t, "env", SourceEnv.String())
+	assert.Equal(t, "argv", SourceArgv.String())
+	assert.Equal(t, "Source(7)", Source(7).String())
+}
+
+func TestProvenance(t *testing.T) {
+	assert.Equal(t, "default", Provenance{}.String())
+	assert.Equal(t, "file:output:4", Provenance{Source: SourceFile, Key: "output", Line: 4}.String())
+	assert.Equal(t, "env:CURL_OUTPUT", Provenance{Source: SourceEnv, Key: "CURL_OUTPUT"}.String())
+	assert.Equal(t, "argv[3]", Provenance{Source: SourceArgv, TokenIndex: 3}.String())
+}
+
+func TestEnvKeyWithPrefix(t *testing.T) {
+	envKey := EnvKeyWithPrefix("CURL_")
+	assert.Equal(t, "CURL_SHOW_ERROR", envKey(&Option{Prefix: "--", Name: "show-error"}))
+}
+
+// newTestCurlParser returns a curl-like parser for testing the configuration files
+// and the resolver, where each option but `--http` has a short alias.
+func newTestCurlParser() *Parser {
+	px := NewParser()
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddOptionWithArgumentRequired('H', "header")
+	px.AddOptionWithArgumentRequired('o', "output")
+	px.AddOptionWithArgumentNone('s', "silent")
+	px.AddOption(&Option{
+		AllowedValues: []string{"1.0", "1.1", "2"},
+		DefaultValue:  "1.1",
+		Prefix:        "--",
+		Name:          "http",
+		Type:          OptionTypeStandaloneArgumentOptional,
+	})
+	px.AddOptionWithArgumentNone('v', "verbose")
+	return px
+}
+
+// flattenSettings maps each setting name to its values and provenances.
+func flattenSettings(settings []Setting) map[string][]string {
+	out := make(map[string][]string)
+	for _, setting := range settings {
+		for _, value := range setting.Values {
+			out[setting.Option.Name] = append(out[setting.Option.Name], value.Value+" "+value.Provenance.String())
+		}
+	}
+	return out
+}
+
+func TestResolver(t *testing.T) {
+	t.Run("layers and merge policies", func(t *testing.T) {
+		px := newTestCurlParser()
+		values, err := px.Parse([]string{"-H", "c: 3", "-s", "--header=d: 4", "-o", "x.txt", "-o", "y.txt"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		file, err := px.ParseConfig(strings.NewReader("header = a: 1\noutput = file.txt\nheader = b: 2\nhttp = 2\nsilent = false\n"), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		rx := &Resolver{
+			Parser: px,
+			File:   file,
+			EnvKey: EnvKeyWithPrefix("CURL_"),
+			LookupEnv: func(key string) (string, bool) {
+				env := map[string]string{"CURL_HTTP": "1.0", "CURL_HEADER": "e: 5"}
+				value, found := env[key]
+				return value, found
+			},
+			Merge: func(option *Option) MergePolicy {
+				if option.Name == "header" {
+					return MergeAppend
+				}
+				return MergeOverride
+			},
+		}
+		settings, err := rx.Resolve(values)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expect := map[string][]string{
+			"header": {
+				"a: 1 file:header:1",
+				"b: 2 file:header:3",
+				"e: 5 env:CURL_HEADER",
+				"c: 3 argv[0]",
+				"d: 4 argv[3]",
+			},
+			"output":  {"y.txt argv[6]"},
+			"silent":  {"true argv[2]"},
+			"http":    {"1.0 env:CURL_HTTP"},
+			"verbose": {" default"},
+		}
+		assert.Equal(t, expect, flattenSettings(settings))
+		assert.Equal(t, MergeAppend, settings[0].Policy)
+		assert.Equal(t, "d: 4", settings[0].Value().Value)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		rx := &Resolver{Parser: newTestCurlParser()}
+		settings, err := rx.Resolve(nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		expect := map[string][]string{
+			"header":  {" default"},
+			"output":  {" default"},
+			"silent":  {" default"},
+			"http":    {"1.1 default"},
+			"verbose": {" default"},
+		}
+		assert.Equal(t, expect, flattenSettings(settings))
+	})
+
+	t.Run("file values", func(t *testing.T) {
+		px := newTestCurlParser()
+		file, err := px.ParseConfig(strings.NewReader("# comment\nsilent\n\nhttp\n"), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		rx := &Resolver{Parser: px, File: file}
+		settings, err := rx.Resolve(nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "true file:silent:2", flattenSettings(settings)["silent"][0])
+		assert.Equal(t, 2, settings[2].Value().Provenance.Line)
+		assert.Equal(t, "1.1 file:http:4", flattenSettings(settings)["http"][0])
+		assert.Equal(t, 4, settings[3].Value().Provenance.Line)
+	})
+
+	t.Run("values of other parsers", func(t *testing.T) {
+		other := newTestCurlParser()
+		file, err := other.ParseConfig(strings.NewReader("output = x.txt\n"), "curlrc")
+		if !assert.NoError(t, err) {
+			return
+		}
+		rx := &Resolver{Parser: newTestCurlParser(), File: file}
+		settings, err := rx.Resolve(nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{" default"}, flattenSettings(settings)["output"])
+	})
+
+	t.Run("options taking no argument", func(t *testing.T) {
+		px := newTestCurlParser()
+		values, err := px.Parse([]string{"--silent"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, tc := range []struct {
+			env    string
+			expect []string
+		}{
+			{"true", []string{"true env:CURL_SILENT", "true argv[0]"}},
+			{"false", []string{"true argv[0]"}},
+		} {
+			rx := &Resolver{
+				Parser: px,
+				EnvKey: EnvKeyWithPrefix("CURL_"),
+				LookupEnv: func(key string) (string, bool) {
+					return tc.env, key == "CURL_SILENT"
+				},
+				Merge: func(option *Option) MergePolicy {
+					return MergeAppend
+				},
+			}
+			settings, err := rx.Resolve(values)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expect, flattenSettings(settings)["silent"])
+		}
+	})
+
+	t.Run("invalid env value for an option taking no argument", func(t *testing.T) {
+		rx := &Resolver{
+			Parser: newTestCurlParser(),
+			EnvKey: EnvKeyWithPrefix("CURL_"),
+			LookupEnv: func(key string) (string, bool) {
+				return "0", key == "CURL_SILENT"
+			},
+		}
+		settings, err := rx.Resolve(nil)
+		assert.Nil(t, settings)
+		assert.Equal(t, "option requires no argument: --silent", err.Error())
+	})
+
+	t.Run("early options", func(t *testing.T) {
+		px := newTestCurlParser()
+		px.AddEarlyOption('h', "help")
+		values, err := px.Parse([]string{"--help"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		rx := &Resolver{Parser: px}
+		settings, err := rx.Resolve(values)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotContains(t, flattenSettings(settings), "help")
+		assert.Len(t, settings, 5)
+	})
+
+	t.Run("invalid env value", func(t *testing.T) {
+		rx := &Resolver{
+			Parser: newTestCurlParser(),
+			EnvKey: EnvKeyWithPrefix(""),
+			LookupEnv: func(key string) (string, bool) {
+				return "3", key == "HTTP"
+			},
+		}
+		settings, err := rx.Resolve(nil)
+		assert.Nil(t, settings)
+		var errvalue ErrOptionValueNotAllowed
+		assert.True(t, errors.As(err, &errvalue))
+	})
+
+	t.Run("invalid parser", func(t *testing.T) {
+		rx := &Resolver{Parser: &Parser{Options: []*Option{{Prefix: "-", Name: "xy", Type: OptionTypeGroupableArgumentNone}}}}
+		settings, err := rx.Resolve(nil)
+		assert.Nil(t, settings)
+		var errvalue ErrTooLongGroupableOptionName
+		assert.True(t, errors.As(err, &errvalue))
+	})
+}
@@ -0,0 +1,108 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import "fmt"
+
+// ErrRoundTrip indicates that parsing again the command line reconstructed
+// using [Value.Strings] does not yield equivalent values.
+type ErrRoundTrip struct {
+	// Args contains the reconstructed command line.
+	Args []string
+
+	// Err is the error that occurred when parsing Args, if any.
+	Err error
+
+	// Index is the index of the first value that differs, when Err is nil.
+	Index int
+}
+
+var _ error = ErrRoundTrip{}
+
+// Error returns a string representation of this error.
+func (err ErrRoundTrip) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("cannot parse reconstructed command line %q: %s", err.Args, err.Err.Error())
+	}
+	return fmt.Sprintf("reconstructed command line %q yields a different value at index %d", err.Args, err.Index)
+}
+
+// Unwrap returns the underlying error.
+func (err ErrRoundTrip) Unwrap() error {
+	return err.Err
+}
+
+// ErrUnsupportedValue indicates that [CheckRoundTrip] cannot compare a [Value]
+// implementation other than [ValueOption], [ValuePositionalArgument], and
+// [ValueOptionsArgumentsSeparator].
+type ErrUnsupportedValue struct {
+	// Value is the offending value.
+	Value Value
+}
+
+var _ error = ErrUnsupportedValue{}
+
+// Error returns a string representation of this error.
+func (err ErrUnsupportedValue) Error() string {
+	return fmt.Sprintf("unsupported value type: %T", err.Value)
+}
+
+// CheckRoundTrip ensures that parsing with the given [*Parser] the command line
+// reconstructed from the values using [Value.Strings] yields equivalent values,
+// which is what [Normalize] and the commands re-emitting parsed values rely on.
+//
+// The values MUST have been returned by the same [*Parser]. We compare values
+// ignoring their tokens, since the reconstructed command line may have a
+// different layout (e.g., `-o FILE` instead of `-oFILE`).
+//
+// This function returns [ErrUnsupportedValue] when a value is not one of the
+// types that [*Parser.Parse] returns and [ErrRoundTrip] on failure.
+func CheckRoundTrip(px *Parser, values []Value) error {
+	// Reconstruct and parse the command line
+	var args []string
+	for _, value := range values {
+		if _, ok := stripToken(value); !ok {
+			return ErrUnsupportedValue{Value: value}
+		}
+		args = append(args, value.Strings()...)
+	}
+	again, err := px.Parse(args)
+	if err != nil {
+		return ErrRoundTrip{Args: args, Err: err}
+	}
+
+	// Compare the two sequences of values ignoring the tokens
+	for idx := 0; idx < len(values) || idx < len(again); idx++ {
+		if idx >= len(values) || idx >= len(again) || !sameValue(values[idx], again[idx]) {
+			return ErrRoundTrip{Args: args, Index: idx}
+		}
+	}
+	return nil
+}
+
+// sameValue returns whether two values are equal ignoring their tokens.
+func sameValue(left, right Value) bool {
+	left, _ = stripToken(left)
+	right, _ = stripToken(right)
+	return left == right
+}
+
+// stripToken returns a copy of the [Value] without its token, or false
+// when the [Value] is not one of the types that [*Parser.Parse] returns.
+func stripToken(value Value) (Value, bool) {
+	switch value := value.(type) {
+	case ValueOption:
+		value.Tok = nil
+		return value, true
+	case ValuePositionalArgument:
+		value.Tok = nil
+		return value, true
+	case ValueOptionsArgumentsSeparator:
+		value.Tok = nil
+		return value, true
+	default:
+		return nil, false
+	}
+}
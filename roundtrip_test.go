@@ -0,0 +1,290 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTripWords contains the words we use to generate random positional
+// arguments, option names, and option values.
+var roundTripWords = []string{"", "a", "b", "x", "foo", "always", "never", "=", "a=b", "-", "--", "+", "/"}
+
+// newRandomParser generates a random, and usually valid, [*Parser] configuration.
+func newRandomParser(r *rand.Rand) *Parser {
+	px := &Parser{
+		DisablePermute:         r.IntN(2) == 0,
+		MinPositionalArguments: 0,
+		MaxPositionalArguments: math.MaxInt,
+	}
+	if r.IntN(2) == 0 {
+		px.OptionsArgumentsSeparator = "--"
+	}
+
+	// Pick the prefixes following GNU, dig, Go, or Windows conventions
+	var groupable, standalone string
+	switch r.IntN(4) {
+	case 0:
+		groupable, standalone = "-", "--"
+	case 1:
+		groupable, standalone = "-", "+"
+	case 2:
+		standalone = "-"
+		px.PrefixAliases = map[string]string{"--": "-"}
+	default:
+		standalone = "/"
+		px.OptionValueDelimiter = ":"
+		px.CaseInsensitive = r.IntN(2) == 0
+	}
+
+	// Generate the groupable options
+	if groupable != "" {
+		for _, name := range []string{"a", "b", "o", "v"} {
+			if r.IntN(3) == 0 {
+				continue
+			}
+			types := []OptionType{
+				OptionTypeGroupableArgumentNone,
+				OptionTypeGroupableArgumentOptional,
+				OptionTypeGroupableArgumentRequired,
+			}
+			px.AddOption(newRandomOption(r, groupable, name, types[r.IntN(len(types))]))
+		}
+	}
+
+	// Generate the standalone options
+	for _, name := range []string{"color", "file", "help", "verbose"} {
+		if r.IntN(3) == 0 {
+			continue
+		}
+		types := []OptionType{
+			OptionTypeEarlyArgumentNone,
+			OptionTypeStandaloneArgumentNone,
+			OptionTypeStandaloneArgumentOptional,
+			OptionTypeStandaloneArgumentRequired,
+		}
+		px.AddOption(newRandomOption(r, standalone, name, types[r.IntN(len(types))]))
+	}
+
+	// Rarely add an option with a random, possibly invalid, type
+	if r.IntN(16) == 0 {
+		px.AddOption(newRandomOption(r, standalone, "x", OptionType(r.Int64N(256))))
+	}
+
+	// Optionally use named positional argument slots
+	if r.IntN(4) == 0 {
+		px.AddPositionalArgument(
+			&PositionalArgument{Name: "SRC", Optional: true, Variadic: true},
+			&PositionalArgument{Name: "DST", Optional: r.IntN(2) == 0},
+		)
+	}
+	return px
+}
+
+// newRandomOption generates a random [*Option] with the given prefix, name, and type.
+func newRandomOption(r *rand.Rand, prefix, name string, ot OptionType) *Option {
+	option := &Option{
+		DefaultValue: roundTripWords[r.IntN(len(roundTripWords))],
+		Prefix:       prefix,
+		Name:         name,
+		Type:         ot,
+	}
+	if ot == OptionTypeStandaloneArgumentOptional {
+		if r.IntN(2) == 0 {
+			option.SeparateArgument = MatchValues("always", "never")
+		} else {
+			option.KeepEmptyValue = r.IntN(2) == 0
+		}
+	}
+	return option
+}
+
+// newRandomArgs generates random arguments that are likely to be
+// meaningful for the given [*Parser] configuration.
+func newRandomArgs(r *rand.Rand, px *Parser) []string {
+	var args []string
+	for count := r.IntN(8); count > 0; count-- {
+		word := roundTripWords[r.IntN(len(roundTripWords))]
+		if len(px.Options) <= 0 || r.IntN(3) == 0 {
+			args = append(args, word)
+			continue
+		}
+		option := px.Options[r.IntN(len(px.Options))]
+		name := option.Name
+		if r.IntN(4) == 0 {
+			name = strings.ToUpper(name)
+		}
+		switch r.IntN(5) {
+		case 0:
+			args = append(args, option.Prefix+name+"="+word)
+		case 1:
+			args = append(args, option.Prefix+name+px.OptionValueDelimiter+word)
+		case 2:
+			args = append(args, option.Prefix+name+word)
+		default:
+			args = append(args, option.Prefix+name)
+		}
+	}
+	return args
+}
+
+// errParsePanicked indicates that [*Parser.Parse] panicked.
+var errParsePanicked = errors.New("parsing panicked")
+
+// parseNoPanic is like [*Parser.Parse] but converts panics to errors.
+func parseNoPanic(px *Parser, args []string) (values []Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %q: %v", errParsePanicked, args, r)
+		}
+	}()
+	return px.Parse(args)
+}
+
+// checkRoundTrip parses the given arguments and, when parsing succeeds, uses
+// [CheckRoundTrip] to ensure that parsing again yields equivalent values.
+//
+// This function also returns an error when parsing panics.
+func checkRoundTrip(px *Parser, args []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %q: %v", errParsePanicked, args, r)
+		}
+	}()
+
+	// Parse the original command line and ignore parse errors
+	values, err := px.Parse(args)
+	if err != nil {
+		return nil
+	}
+	if err := CheckRoundTrip(px, values); err != nil {
+		return fmt.Errorf("%q: %w", args, err)
+	}
+	return nil
+}
+
+func TestErrRoundTrip(t *testing.T) {
+	t.Run("with error", func(t *testing.T) {
+		err := ErrRoundTrip{Args: []string{"-x"}, Err: ErrUnknownOption{Prefix: "-", Name: "x"}}
+		assert.Equal(t, `cannot parse reconstructed command line ["-x"]: unknown option: -x`, err.Error())
+		assert.ErrorAs(t, err, &ErrUnknownOption{})
+	})
+
+	t.Run("without error", func(t *testing.T) {
+		err := ErrRoundTrip{Args: []string{"--color", "always"}, Index: 0}
+		assert.Equal(t, `reconstructed command line ["--color" "always"] yields a different value at index 0`, err.Error())
+	})
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	px := NewParser()
+	px.SetMinMaxPositionalArguments(0, 1)
+	px.AddOptionWithArgumentNone('v', "verbose")
+
+	t.Run("we return nil when the values round trip", func(t *testing.T) {
+		values, err := px.Parse([]string{"x", "-v"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, CheckRoundTrip(px, values))
+	})
+
+	t.Run("we return an error when parsing fails", func(t *testing.T) {
+		values := []Value{
+			ValuePositionalArgument{Value: "x"},
+			ValuePositionalArgument{Value: "y"},
+		}
+		var errvalue ErrRoundTrip
+		if assert.ErrorAs(t, CheckRoundTrip(px, values), &errvalue) {
+			assert.Equal(t, []string{"x", "y"}, errvalue.Args)
+			assert.Equal(t, ErrTooManyPositionalArguments{Max: 1, Have: 2}, errvalue.Err)
+		}
+	})
+
+	t.Run("we return an error when the values differ", func(t *testing.T) {
+		values := []Value{ValuePositionalArgument{Value: "-v"}}
+		assert.Equal(t, ErrRoundTrip{Args: []string{"-v"}, Index: 0}, CheckRoundTrip(px, values))
+	})
+
+	t.Run("we return an error for values that Parse does not return", func(t *testing.T) {
+		values := []Value{LineValue{Value: ValuePositionalArgument{Value: "x"}}}
+		assert.Equal(t, ErrUnsupportedValue{Value: values[0]}, CheckRoundTrip(px, values))
+	})
+}
+
+func TestErrUnsupportedValue(t *testing.T) {
+	err := ErrUnsupportedValue{Value: LineValue{}}
+	assert.Equal(t, "unsupported value type: flagparser.LineValue", err.Error())
+}
+
+func TestRoundTripProperty(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 20000 {
+		px := newRandomParser(r)
+		args := newRandomArgs(r, px)
+		if err := checkRoundTrip(px, args); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRoundTripKnownCases(t *testing.T) {
+	px := NewParser()
+	px.SetMinMaxPositionalArguments(0, math.MaxInt)
+	px.AddOptionWithArgumentNone('v', "verbose")
+	px.AddOptionWithArgumentRequired('o', "output")
+	px.AddOption(&Option{
+		DefaultValue:     "auto",
+		SeparateArgument: MatchValues("always", "never"),
+		Prefix:           "--",
+		Name:             "color",
+		Type:             OptionTypeStandaloneArgumentOptional,
+	})
+
+	cases := [][]string{
+		{"-vo", "--", "--", "-v"},
+		{"--output=", "x", "--color="},
+		{"--color", "always", "x", "--color"},
+		{"-o", "-v", "--output", "--color"},
+		{"x", "--", "--color", "-v"},
+		{"always", "--color"},
+	}
+	for _, args := range cases {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			assert.NoError(t, checkRoundTrip(px, args))
+		})
+	}
+
+	t.Run("separate argument ambiguity", func(t *testing.T) {
+		// Permutation moves `always` after `--color`, which must not take it
+		values, err := px.Parse([]string{"always", "--color"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"--color="}, values[0].Strings())
+		assert.NoError(t, CheckRoundTrip(px, values))
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(uint64(0), "-vo\x00--\x00x")
+	f.Add(uint64(1), "--color\x00always\x00--file=a=b")
+	f.Add(uint64(2), "+verbose\x00-ab\x00--\x00-v")
+	f.Add(uint64(3), "/help\x00/file:x\x00/")
+	f.Fuzz(func(t *testing.T, seed uint64, input string) {
+		px := newRandomParser(rand.New(rand.NewPCG(seed, seed)))
+		args := strings.Split(input, "\x00")
+		if err := checkRoundTrip(px, args); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,419 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParserSpec is a serializable description of a [*Parser].
+//
+// The struct tags allow encoding and decoding specs using JSON, YAML, and TOML,
+// for which we provide loaders (e.g., [LoadParserSpecYAML]) and dumpers (e.g.,
+// [DumpParserSpecTOML]). For other formats, decode a [*ParserSpec] using your
+// favourite library and then call [*ParserSpec.NewParser], which validates it.
+//
+// A spec cannot describe Go functions, therefore [*Parser.Spec] fails when the
+// [*Option] SeparateArgument or Validator fields, or the [*PositionalArgument]
+// Validator field, are set, since they change what we parse. We do not serialize
+// the [*Parser] LookupEnv and Logger fields. Set them after loading, if needed.
+type ParserSpec struct {
+	// DisablePermute is the [*Parser] DisablePermute field.
+	DisablePermute bool `json:"disable_permute,omitempty" yaml:"disable_permute,omitempty" toml:"disable_permute,omitempty"`
+
+	// HonorPosixlyCorrect is the [*Parser] HonorPosixlyCorrect field.
+	HonorPosixlyCorrect bool `json:"honor_posixly_correct,omitempty" yaml:"honor_posixly_correct,omitempty" toml:"honor_posixly_correct,omitempty"`
+
+	// MinPositionalArguments is the [*Parser] MinPositionalArguments field.
+	MinPositionalArguments int `json:"min_positional_arguments,omitempty" yaml:"min_positional_arguments,omitempty" toml:"min_positional_arguments,omitzero"`
+
+	// MaxPositionalArguments is the [*Parser] MaxPositionalArguments field.
+	//
+	// A negative value means that there is no maximum (i.e., [math.MaxInt]).
+	MaxPositionalArguments int `json:"max_positional_arguments,omitempty" yaml:"max_positional_arguments,omitempty" toml:"max_positional_arguments,omitzero"`
+
+	// OptionsArgumentsSeparator is the [*Parser] OptionsArgumentsSeparator field.
+	OptionsArgumentsSeparator string `json:"options_arguments_separator,omitempty" yaml:"options_arguments_separator,omitempty" toml:"options_arguments_separator,omitempty"`
+
+	// OptionValueDelimiter is the [*Parser] OptionValueDelimiter field.
+	OptionValueDelimiter string `json:"option_value_delimiter,omitempty" yaml:"option_value_delimiter,omitempty" toml:"option_value_delimiter,omitempty"`
+
+	// CaseInsensitive is the [*Parser] CaseInsensitive field.
+	CaseInsensitive bool `json:"case_insensitive,omitempty" yaml:"case_insensitive,omitempty" toml:"case_insensitive,omitempty"`
+
+	// PrefixAliases is the [*Parser] PrefixAliases field.
+	PrefixAliases map[string]string `json:"prefix_aliases,omitempty" yaml:"prefix_aliases,omitempty" toml:"prefix_aliases,omitempty"`
+
+	// Options describes the [*Parser] Options field.
+	Options []OptionSpec `json:"options,omitempty" yaml:"options,omitempty" toml:"options,omitempty"`
+
+	// PositionalArguments describes the [*Parser] PositionalArguments field.
+	PositionalArguments []PositionalArgumentSpec `json:"positional_arguments,omitempty" yaml:"positional_arguments,omitempty" toml:"positional_arguments,omitempty"`
+}
+
+// OptionSpec is the serializable description of an [*Option].
+type OptionSpec struct {
+	// Prefix is the [*Option] Prefix field.
+	Prefix string `json:"prefix" yaml:"prefix" toml:"prefix"`
+
+	// Name is the [*Option] Name field.
+	Name string `json:"name" yaml:"name" toml:"name"`
+
+	// Type is the name of the [*Option] Type field, as returned
+	// by [OptionType.String] (e.g., `GroupableArgumentNone`).
+	Type string `json:"type" yaml:"type" toml:"type"`
+
+	// DefaultValue is the [*Option] DefaultValue field.
+	DefaultValue string `json:"default_value,omitempty" yaml:"default_value,omitempty" toml:"default_value,omitempty"`
+
+	// KeepEmptyValue is the [*Option] KeepEmptyValue field.
+	KeepEmptyValue bool `json:"keep_empty_value,omitempty" yaml:"keep_empty_value,omitempty" toml:"keep_empty_value,omitempty"`
+
+	// AllowedValues is the [*Option] AllowedValues field.
+	AllowedValues []string `json:"allowed_values,omitempty" yaml:"allowed_values,omitempty" toml:"allowed_values,omitempty"`
+
+	// Alias is the name of the [*Option] Alias, if any.
+	Alias string `json:"alias,omitempty" yaml:"alias,omitempty" toml:"alias,omitempty"`
+}
+
+// PositionalArgumentSpec is the serializable description of a [*PositionalArgument].
+type PositionalArgumentSpec struct {
+	// Name is the [*PositionalArgument] Name field.
+	Name string `json:"name" yaml:"name" toml:"name"`
+
+	// Optional is the [*PositionalArgument] Optional field.
+	Optional bool `json:"optional,omitempty" yaml:"optional,omitempty" toml:"optional,omitempty"`
+
+	// Variadic is the [*PositionalArgument] Variadic field.
+	Variadic bool `json:"variadic,omitempty" yaml:"variadic,omitempty" toml:"variadic,omitempty"`
+}
+
+// ErrInvalidSpec indicates that a [*ParserSpec] is not valid.
+type ErrInvalidSpec struct {
+	// Location is the location of the error inside the spec, which is
+	// either a path (e.g., `options[2].type`) or, when decoding fails,
+	// a position (e.g., `line 7, column 15`).
+	Location string
+
+	// Err is the underlying error.
+	Err error
+}
+
+var _ error = ErrInvalidSpec{}
+
+// Error returns a string representation of this error.
+func (err ErrInvalidSpec) Error() string {
+	return fmt.Sprintf("invalid parser spec: %s: %s", err.Location, err.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (err ErrInvalidSpec) Unwrap() error {
+	return err.Err
+}
+
+// ErrUnknownSpecField indicates that a spec contains a field that
+// does not exist inside the corresponding [ParserSpec] struct.
+type ErrUnknownSpecField struct {
+	// Name is the name of the unknown field.
+	Name string
+}
+
+var _ error = ErrUnknownSpecField{}
+
+// Error returns a string representation of this error.
+func (err ErrUnknownSpecField) Error() string {
+	return fmt.Sprintf("unknown field %q", err.Name)
+}
+
+// ErrUnserializableField indicates that [*Parser.Spec] cannot describe
+// a field of the [*Parser] because it contains a Go function.
+type ErrUnserializableField struct {
+	// Location is the location the field would have inside
+	// the spec (e.g., `options[2].separate_argument`).
+	Location string
+}
+
+var _ error = ErrUnserializableField{}
+
+// Error returns a string representation of this error.
+func (err ErrUnserializableField) Error() string {
+	return fmt.Sprintf("cannot serialize parser field: %s", err.Location)
+}
+
+// Spec returns the [*ParserSpec] describing the [*Parser].
+//
+// This method does not mutate [*Parser] and is safe to call concurrently.
+//
+// This method returns [ErrUnserializableField] when the [*Parser] uses fields
+// that we cannot serialize. See [ParserSpec] for details.
+func (px *Parser) Spec() (*ParserSpec, error) {
+	spec := &ParserSpec{
+		DisablePermute:            px.DisablePermute,
+		HonorPosixlyCorrect:       px.HonorPosixlyCorrect,
+		MinPositionalArguments:    px.MinPositionalArguments,
+		MaxPositionalArguments:    px.MaxPositionalArguments,
+		OptionsArgumentsSeparator: px.OptionsArgumentsSeparator,
+		OptionValueDelimiter:      px.OptionValueDelimiter,
+		CaseInsensitive:           px.CaseInsensitive,
+		PrefixAliases:             maps.Clone(px.PrefixAliases),
+	}
+	if spec.MaxPositionalArguments == math.MaxInt {
+		spec.MaxPositionalArguments = -1
+	}
+	for idx, option := range px.Options {
+		switch {
+		case option.SeparateArgument != nil:
+			return nil, ErrUnserializableField{Location: fmt.Sprintf("options[%d].separate_argument", idx)}
+		case option.Validator != nil:
+			return nil, ErrUnserializableField{Location: fmt.Sprintf("options[%d].validator", idx)}
+		}
+		ospec := OptionSpec{
+			Prefix:         option.Prefix,
+			Name:           option.Name,
+			Type:           option.Type.String(),
+			DefaultValue:   option.DefaultValue,
+			KeepEmptyValue: option.KeepEmptyValue,
+			AllowedValues:  option.AllowedValues,
+		}
+		if option.Alias != nil {
+			ospec.Alias = option.Alias.Name
+		}
+		spec.Options = append(spec.Options, ospec)
+	}
+	for idx, argument := range px.PositionalArguments {
+		if argument.Validator != nil {
+			return nil, ErrUnserializableField{Location: fmt.Sprintf("positional_arguments[%d].validator", idx)}
+		}
+		spec.PositionalArguments = append(spec.PositionalArguments, PositionalArgumentSpec{
+			Name:     argument.Name,
+			Optional: argument.Optional,
+			Variadic: argument.Variadic,
+		})
+	}
+	return spec, nil
+}
+
+// NewParser creates a new [*Parser] from the [*ParserSpec].
+//
+// This method validates the spec using the same checks that [*Parser.Parse]
+// performs and returns [ErrInvalidSpec] wrapping the original error, along
+// with the location of the offending entry, when the spec is not valid.
+func (spec *ParserSpec) NewParser() (*Parser, error) {
+	px := &Parser{
+		DisablePermute:            spec.DisablePermute,
+		HonorPosixlyCorrect:       spec.HonorPosixlyCorrect,
+		MinPositionalArguments:    spec.MinPositionalArguments,
+		MaxPositionalArguments:    spec.MaxPositionalArguments,
+		OptionsArgumentsSeparator: spec.OptionsArgumentsSeparator,
+		OptionValueDelimiter:      spec.OptionValueDelimiter,
+		CaseInsensitive:           spec.CaseInsensitive,
+		PrefixAliases:             maps.Clone(spec.PrefixAliases),
+	}
+	if px.MaxPositionalArguments < 0 {
+		px.MaxPositionalArguments = math.MaxInt
+	}
+
+	// Create the options
+	byName := make(map[string]*Option)
+	for idx, ospec := range spec.Options {
+		option := &Option{
+			DefaultValue:   ospec.DefaultValue,
+			KeepEmptyValue: ospec.KeepEmptyValue,
+			AllowedValues:  ospec.AllowedValues,
+			Prefix:         ospec.Prefix,
+			Name:           ospec.Name,
+		}
+		if err := option.Type.UnmarshalText([]byte(ospec.Type)); err != nil {
+			return nil, ErrInvalidSpec{Location: fmt.Sprintf("options[%d].type", idx), Err: err}
+		}
+		px.AddOption(option)
+		byName[option.Name] = option
+	}
+
+	// Resolve the aliases now that all the options exist
+	for idx, ospec := range spec.Options {
+		if ospec.Alias == "" {
+			continue
+		}
+		alias := byName[ospec.Alias]
+		if alias == nil {
+			return nil, ErrInvalidSpec{
+				Location: fmt.Sprintf("options[%d].alias", idx),
+				Err:      ErrUnknownOption{Name: ospec.Alias},
+			}
+		}
+		px.Options[idx].Alias = alias
+	}
+
+	// Create the positional argument slots
+	for _, aspec := range spec.PositionalArguments {
+		px.AddPositionalArgument(&PositionalArgument{
+			Name:     aspec.Name,
+			Optional: aspec.Optional,
+			Variadic: aspec.Variadic,
+		})
+	}
+
+	// Run the same checks we run when parsing
+	if _, err := newConfig(px); err != nil {
+		return nil, ErrInvalidSpec{Location: specErrorLocation(px, err), Err: err}
+	}
+	return px, nil
+}
+
+// specErrorLocation returns the location of an error returned by [newConfig].
+func specErrorLocation(px *Parser, err error) string {
+	optionIndex := func(option *Option) int {
+		for idx, candidate := range px.Options {
+			if candidate == option {
+				return idx
+			}
+		}
+		return -1
+	}
+	argumentIndex := func(argument *PositionalArgument) int {
+		for idx, candidate := range px.PositionalArguments {
+			if candidate == argument {
+				return idx
+			}
+		}
+		return -1
+	}
+
+	switch err := err.(type) {
+	case ErrInvalidOptionType:
+		return fmt.Sprintf("options[%d].type", optionIndex(err.Option))
+
+	case ErrTooLongGroupableOptionName:
+		return fmt.Sprintf("options[%d].name", optionIndex(err.Option))
+
+	case ErrEmptyOptionName:
+		return fmt.Sprintf("options[%d].name", optionIndex(err.Option))
+
+	case ErrEmptyOptionPrefix:
+		return fmt.Sprintf("options[%d].prefix", optionIndex(err.Option))
+
+	case ErrMultipleOptionsWithSameName:
+		// Blame the second occurrence, which is the duplicate
+		return fmt.Sprintf("options[%d].name", optionIndex(err.Options[1]))
+
+	case ErrAmbiguousPrefix:
+		// Blame the first option whose kind conflicts with a previous option
+		var seen OptionType
+		for idx, option := range px.Options {
+			if option.Prefix != err.Prefix || option.Type.isEarly() {
+				continue
+			}
+			kind := option.Type & (optionKindGroupable | optionKindStandalone)
+			if seen != 0 && seen != kind {
+				return fmt.Sprintf("options[%d].prefix", idx)
+			}
+			seen = kind
+		}
+		return "options"
+
+	case ErrInvalidPrefixAlias:
+		return fmt.Sprintf("prefix_aliases[%q]", err.Alias)
+
+	case ErrEmptyPositionalArgumentName:
+		return fmt.Sprintf("positional_arguments[%d].name", argumentIndex(err.Argument))
+
+	case ErrMultipleVariadicPositionalArguments:
+		// Blame the second variadic slot, which is the duplicate
+		return fmt.Sprintf("positional_arguments[%d].variadic", argumentIndex(err.Arguments[1]))
+
+	default:
+		return "spec"
+	}
+}
+
+// LoadParserSpecJSON reads a JSON [*ParserSpec] from the given reader and
+// creates a new [*Parser] using [*ParserSpec.NewParser].
+//
+// We reject unknown fields. When decoding fails, we return [ErrInvalidSpec]
+// with the line and column of the error as the location. For unknown fields,
+// that is the position of the first key with the same name.
+func LoadParserSpecJSON(r io.Reader) (*Parser, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var spec ParserSpec
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, ErrInvalidSpec{Location: jsonErrorLocation(data, decoder, err), Err: err}
+	}
+	return spec.NewParser()
+}
+
+// jsonErrorLocation returns the line and column where decoding failed.
+func jsonErrorLocation(data []byte, decoder *json.Decoder, err error) string {
+	var (
+		offset    = decoder.InputOffset()
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		// The decoder reads the whole value before reporting unknown fields, so
+		// its offset is the end of the value, and we search for the key instead
+		if index := jsonUnknownFieldIndex(data, err); index >= 0 {
+			offset = int64(index) + 1
+		}
+	}
+
+	// The offset is the number of bytes read when the error occurred and we
+	// want the position of the last byte read, which caused the error
+	offset = min(max(offset-1, 0), int64(len(data)))
+	prefix := data[:offset]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	column := len(prefix) - (bytes.LastIndexByte(prefix, '\n') + 1) + 1
+	return fmt.Sprintf("line %d, column %d", line, column)
+}
+
+// jsonUnknownFieldIndex returns the index of the first key named like
+// the unknown field mentioned by the given error, or -1 if not found.
+func jsonUnknownFieldIndex(data []byte, err error) int {
+	quoted, found := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !found {
+		return -1
+	}
+	name, err := strconv.Unquote(quoted)
+	if err != nil {
+		return -1
+	}
+	key, _ := json.Marshal(name)
+	loc := regexp.MustCompile(regexp.QuoteMeta(string(key)) + `\s*:`).FindIndex(data)
+	if loc == nil {
+		return -1
+	}
+	return loc[0]
+}
+
+// DumpParserSpecJSON writes the indented JSON [*ParserSpec] of the
+// given [*Parser] to the given writer. See [*Parser.Spec] for details.
+func DumpParserSpecJSON(w io.Writer, px *Parser) error {
+	spec, err := px.Spec()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(spec)
+}
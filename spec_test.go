@@ -0,0 +1,313 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// curlParserSpecJSON is the JSON spec of a curl-like parser.
+const curlParserSpecJSON = `{
+  "max_positional_arguments": -1,
+  "options_arguments_separator": "--",
+  "options": [
+    {
+      "prefix": "-",
+      "name": "h",
+      "type": "EarlyArgumentNone",
+      "alias": "help"
+    },
+    {
+      "prefix": "--",
+      "name": "help",
+      "type": "EarlyArgumentNone",
+      "alias": "h"
+    },
+    {
+      "prefix": "-",
+      "name": "o",
+      "type": "GroupableArgumentRequired",
+      "alias": "output"
+    },
+    {
+      "prefix": "--",
+      "name": "output",
+      "type": "StandaloneArgumentRequired",
+      "alias": "o"
+    },
+    {
+      "prefix": "--",
+      "name": "http",
+      "type": "StandaloneArgumentOptional",
+      "default_value": "1.1",
+      "keep_empty_value": true,
+      "allowed_values": [
+        "1.0",
+        "1.1",
+        "2"
+      ]
+    }
+  ],
+  "positional_arguments": [
+    {
+      "name": "URL",
+      "variadic": true
+    }
+  ]
+}
+`
+
+func TestParserSpec(t *testing.T) {
+	t.Run("load and dump round trip", func(t *testing.T) {
+		px, err := LoadParserSpecJSON(strings.NewReader(curlParserSpecJSON))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, math.MaxInt, px.MaxPositionalArguments)
+		assert.Same(t, px.Options[1], px.Options[0].Alias)
+		assert.Same(t, px.Options[0], px.Options[1].Alias)
+
+		var out bytes.Buffer
+		assert.NoError(t, DumpParserSpecJSON(&out, px))
+		assert.Equal(t, curlParserSpecJSON, out.String())
+	})
+
+	t.Run("parser round trip", func(t *testing.T) {
+		px := NewParser()
+		px.DisablePermute = true
+		px.HonorPosixlyCorrect = true
+		px.SetMinMaxPositionalArguments(1, 4)
+		px.AddEarlyOption('h', "help")
+		px.AddOptionWithArgumentNone('v', "verbose")
+		px.AddLongOptionWithArgumentOptional("color", "auto")
+
+		other := newParserFromSpec(t, px)
+		assert.Equal(t, mustSpec(t, px), mustSpec(t, other))
+
+		args := []string{"-vh", "--color=never", "x"}
+		expectValues, expectErr := px.Parse(args)
+		gotValues, gotErr := other.Parse(args)
+		assert.Equal(t, expectErr, gotErr)
+		assert.Equal(t, len(expectValues), len(gotValues))
+	})
+
+	t.Run("preset round trip", func(t *testing.T) {
+		for _, px := range []*Parser{NewGoParser(), NewPOSIXParser(), NewWindowsParser(), NewDigParser()} {
+			px.AddOption(&Option{Prefix: "-", Name: "x", Type: OptionTypeStandaloneArgumentNone})
+			other := newParserFromSpec(t, px)
+			assert.Equal(t, mustSpec(t, px), mustSpec(t, other))
+		}
+	})
+
+	t.Run("the loaded parser works", func(t *testing.T) {
+		px, err := LoadParserSpecJSON(strings.NewReader(curlParserSpecJSON))
+		if !assert.NoError(t, err) {
+			return
+		}
+		values, err := px.Parse([]string{"https://example.com/", "-o", "index.html", "--http=2"})
+		assert.NoError(t, err)
+		var got []string
+		for _, value := range values {
+			got = append(got, value.Strings()...)
+		}
+		assert.Equal(t, []string{"-o", "index.html", "--http=2", "https://example.com/"}, got)
+	})
+
+	t.Run("an invalid option type does not round trip", func(t *testing.T) {
+		px := &Parser{Options: []*Option{{Prefix: "-", Name: "x"}}}
+		spec := mustSpec(t, px)
+		assert.Equal(t, "OptionType(0)", spec.Options[0].Type)
+		_, err := spec.NewParser()
+		assert.True(t, errors.Is(err, ErrInvalidOptionTypeName{Name: "OptionType(0)"}))
+	})
+}
+
+func TestParser_SpecErrors(t *testing.T) {
+	validator := func(value string) error { return nil }
+
+	cases := []struct {
+		name   string
+		parser *Parser
+		expect string
+	}{
+		{
+			name: "option separate argument",
+			parser: &Parser{Options: []*Option{
+				{Prefix: "--", Name: "verbose", Type: OptionTypeStandaloneArgumentNone},
+				{SeparateArgument: MatchValues("always"), Prefix: "--", Name: "color", Type: OptionTypeStandaloneArgumentOptional},
+			}},
+			expect: "cannot serialize parser field: options[1].separate_argument",
+		},
+
+		{
+			name:   "option validator",
+			parser: &Parser{Options: []*Option{{Validator: validator, Prefix: "-", Name: "p", Type: OptionTypeGroupableArgumentRequired}}},
+			expect: "cannot serialize parser field: options[0].validator",
+		},
+
+		{
+			name:   "positional argument validator",
+			parser: &Parser{PositionalArguments: []*PositionalArgument{{Name: "PORT", Validator: validator}}},
+			expect: "cannot serialize parser field: positional_arguments[0].validator",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := tc.parser.Spec()
+			assert.Nil(t, spec)
+			var errvalue ErrUnserializableField
+			if assert.ErrorAs(t, err, &errvalue) {
+				assert.Equal(t, tc.expect, err.Error())
+			}
+			assert.Error(t, DumpParserSpecJSON(&bytes.Buffer{}, tc.parser))
+			assert.Error(t, DumpParserSpecYAML(&bytes.Buffer{}, tc.parser))
+			assert.Error(t, DumpParserSpecTOML(&bytes.Buffer{}, tc.parser))
+		})
+	}
+}
+
+// mustSpec returns the [*ParserSpec] of the given [*Parser] or fails the test.
+func mustSpec(t *testing.T, px *Parser) *ParserSpec {
+	t.Helper()
+	spec, err := px.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// newParserFromSpec creates a [*Parser] from the spec of the given [*Parser] or fails the test.
+func newParserFromSpec(t *testing.T, px *Parser) *Parser {
+	t.Helper()
+	other, err := mustSpec(t, px).NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return other
+}
+
+func TestLoadParserSpecJSONErrors(t *testing.T) {
+	type testcase struct {
+		name   string
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{
+			name:   "syntax error",
+			input:  "{\n  \"options\": [\n    {\"name\": \"x\",}\n  ]\n}\n",
+			expect: `invalid parser spec: line 3, column 18: invalid character '}' looking for beginning of object key string`,
+		},
+
+		{
+			name:   "wrong field type",
+			input:  "{\n  \"disable_permute\": \"yes\"\n}\n",
+			expect: `invalid parser spec: line 2, column 26: json: cannot unmarshal string into Go struct field ParserSpec.disable_permute of type bool`,
+		},
+
+		{
+			name:   "unknown field",
+			input:  "{\n  \"permute\": true\n}\n",
+			expect: `invalid parser spec: line 2, column 3: json: unknown field "permute"`,
+		},
+
+		{
+			name:   "unknown nested field",
+			input:  `{"options": [{"prefix": "-", "name": "x", "type": "GroupableArgumentNone"}, {"Prefix": "-", "default": ""}]}`,
+			expect: `invalid parser spec: line 1, column 93: json: unknown field "default"`,
+		},
+
+		{
+			name:   "unknown field after a map",
+			input:  `{"prefix_aliases": {"/": "-"}, "positional_arguments": [{"name": "SRC", "validator": "x"}]}`,
+			expect: `invalid parser spec: line 1, column 73: json: unknown field "validator"`,
+		},
+
+		{
+			name:   "invalid option type name",
+			input:  `{"options": [{"prefix": "-", "name": "x", "type": "Groupable"}]}`,
+			expect: `invalid parser spec: options[0].type: invalid option type name: "Groupable"`,
+		},
+
+		{
+			name:   "unknown alias",
+			input:  `{"options": [{"prefix": "-", "name": "x", "type": "GroupableArgumentNone", "alias": "y"}]}`,
+			expect: `invalid parser spec: options[0].alias: unknown option: y`,
+		},
+
+		{
+			name:   "too long groupable option name",
+			input:  `{"options": [{"prefix": "-", "name": "xy", "type": "GroupableArgumentNone"}]}`,
+			expect: `invalid parser spec: options[0].name: groupable option names should be a single byte, found: -xy (GroupableArgumentNone)`,
+		},
+
+		{
+			name:   "empty option name",
+			input:  `{"options": [{"prefix": "-", "name": "x", "type": "GroupableArgumentNone"}, {"prefix": "--", "type": "StandaloneArgumentNone"}]}`,
+			expect: `invalid parser spec: options[1].name: option name cannot be empty: -- (StandaloneArgumentNone)`,
+		},
+
+		{
+			name:   "empty option prefix",
+			input:  `{"options": [{"name": "x", "type": "StandaloneArgumentNone"}]}`,
+			expect: `invalid parser spec: options[0].prefix: option prefix cannot be empty: x (StandaloneArgumentNone)`,
+		},
+
+		{
+			name:   "missing option type",
+			input:  `{"options": [{"prefix": "-", "name": "x"}]}`,
+			expect: `invalid parser spec: options[0].type: invalid option type name: ""`,
+		},
+
+		{
+			name:   "duplicate option name",
+			input:  `{"options": [{"prefix": "-", "name": "x", "type": "GroupableArgumentNone"}, {"prefix": "--", "name": "x", "type": "StandaloneArgumentNone"}]}`,
+			expect: `invalid parser spec: options[1].name: multiple options with "x" name`,
+		},
+
+		{
+			name:   "ambiguous prefix",
+			input:  `{"options": [{"prefix": "-", "name": "h", "type": "EarlyArgumentNone"}, {"prefix": "-", "name": "x", "type": "GroupableArgumentNone"}, {"prefix": "-", "name": "yy", "type": "StandaloneArgumentNone"}]}`,
+			expect: `invalid parser spec: options[2].prefix: prefix "-" is used for both standalone and groupable options`,
+		},
+
+		{
+			name:   "invalid prefix alias",
+			input:  `{"prefix_aliases": {"--": "+"}, "options": [{"prefix": "-", "name": "x", "type": "StandaloneArgumentNone"}]}`,
+			expect: `invalid parser spec: prefix_aliases["--"]: invalid prefix alias "--" for prefix "+"`,
+		},
+
+		{
+			name:   "empty positional argument name",
+			input:  `{"positional_arguments": [{"name": "SRC"}, {}]}`,
+			expect: `invalid parser spec: positional_arguments[1].name: positional argument name cannot be empty`,
+		},
+
+		{
+			name:   "multiple variadic positional arguments",
+			input:  `{"positional_arguments": [{"name": "SRC", "variadic": true}, {"name": "DST", "variadic": true}]}`,
+			expect: `invalid parser spec: positional_arguments[1].variadic: multiple variadic positional arguments: 2`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			px, err := LoadParserSpecJSON(strings.NewReader(tc.input))
+			assert.Nil(t, px)
+			var errvalue ErrInvalidSpec
+			if assert.True(t, errors.As(err, &errvalue)) {
+				assert.Equal(t, tc.expect, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LoadParserSpecTOML reads a TOML [*ParserSpec] from the given reader and
+// creates a new [*Parser] using [*ParserSpec.NewParser].
+//
+// We reject unknown fields, in which case we return [ErrInvalidSpec] wrapping
+// [ErrUnknownSpecField] with the key of the field (e.g., `options.default`)
+// as the location. When decoding fails, we return [ErrInvalidSpec] with the
+// line, and the column when known, of the error as the location.
+func LoadParserSpecTOML(r io.Reader) (*Parser, error) {
+	var spec ParserSpec
+	metadata, err := toml.NewDecoder(r).Decode(&spec)
+	if err != nil {
+		return nil, ErrInvalidSpec{Location: tomlErrorLocation(err), Err: err}
+	}
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		key := undecoded[0]
+		return nil, ErrInvalidSpec{Location: key.String(), Err: ErrUnknownSpecField{Name: key[len(key)-1]}}
+	}
+	return spec.NewParser()
+}
+
+// tomlErrorLocation returns the location where decoding failed.
+func tomlErrorLocation(err error) string {
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Sprintf("line %d, column %d", parseErr.Position.Line, parseErr.Position.Col)
+	}
+	return specErrorLine(err)
+}
+
+// DumpParserSpecTOML writes the TOML [*ParserSpec] of the given
+// [*Parser] to the given writer. See [*Parser.Spec] for details.
+func DumpParserSpecTOML(w io.Writer, px *Parser) error {
+	spec, err := px.Spec()
+	if err != nil {
+		return err
+	}
+	encoder := toml.NewEncoder(w)
+	encoder.Indent = ""
+	return encoder.Encode(spec)
+}
@@ -0,0 +1,124 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// curlParserSpecTOML is the TOML spec of the parser described by [curlParserSpecJSON].
+const curlParserSpecTOML = `max_positional_arguments = -1
+options_arguments_separator = "--"
+
+[[options]]
+prefix = "-"
+name = "h"
+type = "EarlyArgumentNone"
+alias = "help"
+
+[[options]]
+prefix = "--"
+name = "help"
+type = "EarlyArgumentNone"
+alias = "h"
+
+[[options]]
+prefix = "-"
+name = "o"
+type = "GroupableArgumentRequired"
+alias = "output"
+
+[[options]]
+prefix = "--"
+name = "output"
+type = "StandaloneArgumentRequired"
+alias = "o"
+
+[[options]]
+prefix = "--"
+name = "http"
+type = "StandaloneArgumentOptional"
+default_value = "1.1"
+keep_empty_value = true
+allowed_values = ["1.0", "1.1", "2"]
+
+[[positional_arguments]]
+name = "URL"
+variadic = true
+`
+
+func TestParserSpecTOML(t *testing.T) {
+	t.Run("load and dump round trip", func(t *testing.T) {
+		px, err := LoadParserSpecTOML(strings.NewReader(curlParserSpecTOML))
+		if !assert.NoError(t, err) {
+			return
+		}
+		var out bytes.Buffer
+		assert.NoError(t, DumpParserSpecTOML(&out, px))
+		assert.Equal(t, curlParserSpecTOML, out.String())
+	})
+
+	t.Run("we load the same parser as JSON", func(t *testing.T) {
+		px, err := LoadParserSpecTOML(strings.NewReader(curlParserSpecTOML))
+		if !assert.NoError(t, err) {
+			return
+		}
+		other, err := LoadParserSpecJSON(strings.NewReader(curlParserSpecJSON))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, mustSpec(t, other), mustSpec(t, px))
+	})
+}
+
+func TestLoadParserSpecTOMLErrors(t *testing.T) {
+	type testcase struct {
+		name   string
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{
+			name:   "syntax error",
+			input:  "disable_permute = true\noptions = [\n",
+			expect: `invalid parser spec: line 2, column 12: toml: line 2 (last key "options"): unexpected EOF; expected value`,
+		},
+
+		{
+			name:   "wrong field type",
+			input:  "disable_permute = \"yes\"\n",
+			expect: `invalid parser spec: line 1: toml: line 1 (last key "disable_permute"): incompatible types: TOML value has type string; destination has type boolean`,
+		},
+
+		{
+			name:   "unknown nested field",
+			input:  "[[options]]\nprefix = \"-\"\nname = \"x\"\ndefault = \"y\"\n",
+			expect: `invalid parser spec: options.default: unknown field "default"`,
+		},
+
+		{
+			name:   "invalid option type name",
+			input:  "[[options]]\nprefix = \"-\"\nname = \"x\"\ntype = \"Groupable\"\n",
+			expect: `invalid parser spec: options[0].type: invalid option type name: "Groupable"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			px, err := LoadParserSpecTOML(strings.NewReader(tc.input))
+			assert.Nil(t, px)
+			var errvalue ErrInvalidSpec
+			if assert.True(t, errors.As(err, &errvalue)) {
+				assert.Equal(t, tc.expect, err.Error())
+			}
+		})
+	}
+}
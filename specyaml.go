@@ -0,0 +1,54 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"io"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadParserSpecYAML reads a YAML [*ParserSpec] from the given reader and
+// creates a new [*Parser] using [*ParserSpec.NewParser].
+//
+// We reject unknown fields. When decoding fails, we return [ErrInvalidSpec]
+// with the line of the error as the location.
+func LoadParserSpecYAML(r io.Reader) (*Parser, error) {
+	var spec ParserSpec
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, ErrInvalidSpec{Location: specErrorLine(err), Err: err}
+	}
+	return spec.NewParser()
+}
+
+// specErrorLineRegexp matches the line number inside YAML and TOML decoding errors.
+var specErrorLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// specErrorLine returns the line mentioned by a YAML or TOML decoding
+// error (e.g., `line 7`), which is the first one when there are many.
+func specErrorLine(err error) string {
+	if match := specErrorLineRegexp.FindStringSubmatch(err.Error()); match != nil {
+		return "line " + match[1]
+	}
+	return "spec"
+}
+
+// DumpParserSpecYAML writes the YAML [*ParserSpec] of the given
+// [*Parser] to the given writer. See [*Parser.Spec] for details.
+func DumpParserSpecYAML(w io.Writer, px *Parser) error {
+	spec, err := px.Spec()
+	if err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(spec); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
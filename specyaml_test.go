@@ -0,0 +1,117 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// curlParserSpecYAML is the YAML spec of the parser described by [curlParserSpecJSON].
+const curlParserSpecYAML = `max_positional_arguments: -1
+options_arguments_separator: --
+options:
+  - prefix: '-'
+    name: h
+    type: EarlyArgumentNone
+    alias: help
+  - prefix: --
+    name: help
+    type: EarlyArgumentNone
+    alias: h
+  - prefix: '-'
+    name: o
+    type: GroupableArgumentRequired
+    alias: output
+  - prefix: --
+    name: output
+    type: StandaloneArgumentRequired
+    alias: o
+  - prefix: --
+    name: http
+    type: StandaloneArgumentOptional
+    default_value: "1.1"
+    keep_empty_value: true
+    allowed_values:
+      - "1.0"
+      - "1.1"
+      - "2"
+positional_arguments:
+  - name: URL
+    variadic: true
+`
+
+func TestParserSpecYAML(t *testing.T) {
+	t.Run("load and dump round trip", func(t *testing.T) {
+		px, err := LoadParserSpecYAML(strings.NewReader(curlParserSpecYAML))
+		if !assert.NoError(t, err) {
+			return
+		}
+		var out bytes.Buffer
+		assert.NoError(t, DumpParserSpecYAML(&out, px))
+		assert.Equal(t, curlParserSpecYAML, out.String())
+	})
+
+	t.Run("we load the same parser as JSON", func(t *testing.T) {
+		px, err := LoadParserSpecYAML(strings.NewReader(curlParserSpecYAML))
+		if !assert.NoError(t, err) {
+			return
+		}
+		other, err := LoadParserSpecJSON(strings.NewReader(curlParserSpecJSON))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, mustSpec(t, other), mustSpec(t, px))
+	})
+}
+
+func TestLoadParserSpecYAMLErrors(t *testing.T) {
+	type testcase struct {
+		name   string
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{
+			name:   "syntax error",
+			input:  "disable_permute: true\n\toptions: []\n",
+			expect: "invalid parser spec: line 2: yaml: line 2: found a tab character that violates indentation",
+		},
+
+		{
+			name:   "wrong field type",
+			input:  "disable_permute: maybe\n",
+			expect: "invalid parser spec: line 1: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `maybe` into bool",
+		},
+
+		{
+			name:   "unknown nested field",
+			input:  "options:\n  - prefix: '-'\n    name: x\n    default: y\n",
+			expect: "invalid parser spec: line 4: yaml: unmarshal errors:\n  line 4: field default not found in type flagparser.OptionSpec",
+		},
+
+		{
+			name:   "invalid option type name",
+			input:  "options:\n  - prefix: '-'\n    name: x\n    type: Groupable\n",
+			expect: `invalid parser spec: options[0].type: invalid option type name: "Groupable"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			px, err := LoadParserSpecYAML(strings.NewReader(tc.input))
+			assert.Nil(t, px)
+			var errvalue ErrInvalidSpec
+			if assert.True(t, errors.As(err, &errvalue)) {
+				assert.Equal(t, tc.expect, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import "iter"
+
+// ParseSeq is like [*Parser.Parse] but returns an iterator that yields
+// each value as soon as we parse it, such that you can start acting on
+// the options before we have consumed all the command line arguments.
+//
+// The sequence contains the same values that [*Parser.Parse] returns,
+// in the same order, according to these semantics:
+//
+//  1. we scan all the arguments for early options first, like [*Parser.Parse]
+//     does, and, when we find one, we only yield the early option
+//
+//  2. we yield each option as soon as we parse it, while we defer the positional
+//     arguments (including the [ValueOptionsArgumentsSeparator]) until we have
+//     consumed all the arguments, because we can only assign them to the slots or
+//     check their number once we know them all, and because, when permutation
+//     is enabled, the options following them come first anyway
+//
+//  3. on error, we yield a nil [Value] along with the error and stop, which
+//     means that you may have already seen some valid options (e.g., when an
+//     unknown option follows them), while [*Parser.Parse] would not return them
+//
+// Use [iter.Pull2] to obtain a pull-style parser with a next function.
+//
+// This method does not mutate [*Parser] and is safe to call concurrently.
+//
+// The args MUST NOT include the program name.
+func (px *Parser) ParseSeq(args []string) iter.Seq2[Value, error] {
+	return func(yield func(Value, error) bool) {
+		// Create the configuration
+		cfg, err := newConfig(px)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		// Tokenize and search for early options like Parse does
+		tokens := px.scan(cfg, args)
+		if value, found := earlyParse(cfg, tokens); found {
+			yield(value, nil)
+			return
+		}
+
+		// Parse one token at a time and yield the options immediately
+		dx := newParseDriver(cfg, tokens)
+		for dx.more() {
+			step := dx.step()
+			if step.err != nil {
+				yield(nil, step.err)
+				return
+			}
+			for _, value := range step.options {
+				if !yield(value, nil) {
+					return
+				}
+			}
+		}
+
+		// Check and yield the deferred positional arguments
+		if err := px.checkPositionals(dx.positionals.values); err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, value := range dx.positionals.values {
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"iter"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collectSeq collects the values and the error of a [*Parser.ParseSeq] sequence.
+func collectSeq(seq iter.Seq2[Value, error]) ([]Value, error) {
+	values := []Value{}
+	for value, err := range seq {
+		if err != nil {
+			return values, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func TestParser_ParseSeq(t *testing.T) {
+	newCurlParser := func() *Parser {
+		px := NewParser()
+		px.SetMinMaxPositionalArguments(1, 1)
+		px.AddOptionWithArgumentNone('f', "fail")
+		px.AddOptionWithArgumentRequired('o', "output")
+		px.AddEarlyOption('h', "help")
+		return px
+	}
+
+	t.Run("options come before the deferred positional arguments", func(t *testing.T) {
+		values, err := collectSeq(newCurlParser().ParseSeq([]string{"https://x.com/", "-fo", "FILE"}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"-f", "-o", "FILE", "https://x.com/"}, flattenValues(values))
+	})
+
+	t.Run("we only yield the early option", func(t *testing.T) {
+		values, err := collectSeq(newCurlParser().ParseSeq([]string{"--nonexistent", "-h", "https://x.com/"}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"-h"}, flattenValues(values))
+	})
+
+	t.Run("we yield the options preceding an invalid option", func(t *testing.T) {
+		values, err := collectSeq(newCurlParser().ParseSeq([]string{"-f", "--nonexistent", "https://x.com/"}))
+		assert.Equal(t, errors.New("unknown option: --nonexistent"), errors.New(err.Error()))
+		assert.Equal(t, []string{"-f"}, flattenValues(values))
+	})
+
+	t.Run("we check the positional arguments at the end", func(t *testing.T) {
+		values, err := collectSeq(newCurlParser().ParseSeq([]string{"-f", "https://x.com/", "https://y.com/"}))
+		assert.Equal(t, ErrTooManyPositionalArguments{Max: 1, Have: 2}, err)
+		assert.Equal(t, []string{"-f"}, flattenValues(values))
+	})
+
+	t.Run("we assign the positional arguments to the slots", func(t *testing.T) {
+		px := newCurlParser()
+		px.AddPositionalArgument(&PositionalArgument{Name: "URL"})
+		values, err := collectSeq(px.ParseSeq([]string{"https://x.com/"}))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(values))
+		assert.Equal(t, "URL", values[0].(ValuePositionalArgument).Argument.Name)
+	})
+
+	t.Run("we report an invalid configuration", func(t *testing.T) {
+		px := newCurlParser()
+		px.AddOption(&Option{Prefix: "--", Name: "fail"})
+		values, err := collectSeq(px.ParseSeq([]string{"-f"}))
+		assert.Error(t, err)
+		assert.Empty(t, values)
+	})
+
+	t.Run("we stop when the caller stops", func(t *testing.T) {
+		var count int
+		for range newCurlParser().ParseSeq([]string{"-f", "-f", "-f", "https://x.com/"}) {
+			count++
+			break
+		}
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("we support pull-style parsing", func(t *testing.T) {
+		next, stop := iter.Pull2(newCurlParser().ParseSeq([]string{"https://x.com/", "-f"}))
+		defer stop()
+		value, err, ok := next()
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"-f"}, value.Strings())
+		value, err, ok = next()
+		assert.True(t, ok)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"https://x.com/"}, value.Strings())
+		_, _, ok = next()
+		assert.False(t, ok)
+	})
+}
+
+func TestParseSeqProperty(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 20000 {
+		px := newRandomParser(r)
+		args := newRandomArgs(r, px)
+		expectValues, expectErr := parseNoPanic(px, args)
+		if errors.Is(expectErr, errParsePanicked) {
+			continue // checked by the round trip property
+		}
+		values, err := collectSeq(px.ParseSeq(args))
+		assert.Equal(t, expectErr, err, "args: %q", args)
+		if expectErr == nil {
+			assert.Equal(t, expectValues, values, "args: %q", args)
+		}
+	}
+}
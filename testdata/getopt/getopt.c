@@ -0,0 +1,83 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * Prints how getopt_long parses a command line, for comparing
+ * with NewGetoptParser. Usage:
+ *
+ *	getopt OPTSTRING LONGOPTS [ARG...]
+ *
+ * where LONGOPTS is a comma separated list of NAME:HAS_ARG entries (e.g.,
+ * `verbose:0,file:1,color:2`). We print one line for each option (i.e.,
+ * `opt:NAME` or `opt:NAME=VALUE`) and positional argument (i.e., `arg:VALUE`),
+ * or just `error` when getopt_long fails.
+ */
+
+#include <getopt.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+int main(int argc, char **argv) {
+	if (argc < 3) {
+		fprintf(stderr, "usage: getopt OPTSTRING LONGOPTS [ARG...]\n");
+		return 2;
+	}
+
+	static struct option longopts[64];
+	int count = 0;
+	for (char *entry = strtok(argv[2], ","); entry != NULL; entry = strtok(NULL, ",")) {
+		char *sep = strchr(entry, ':');
+		if (sep == NULL || count >= 63) {
+			fprintf(stderr, "getopt: invalid LONGOPTS\n");
+			return 2;
+		}
+		*sep = '\0';
+		longopts[count].name = entry;
+		longopts[count].has_arg = atoi(sep + 1);
+		longopts[count].flag = NULL;
+		longopts[count].val = 256 + count;
+		count++;
+	}
+
+	char *optstring = argv[1];
+	argv[2] = argv[0];
+	argc -= 2;
+	argv += 2;
+	opterr = 0;
+
+	char *output = NULL;
+	size_t size = 0;
+	FILE *out = open_memstream(&output, &size);
+	if (out == NULL) {
+		perror("getopt: open_memstream");
+		return 2;
+	}
+
+	for (;;) {
+		int longindex = -1;
+		int ch = getopt_long(argc, argv, optstring, longopts, &longindex);
+		if (ch == -1) {
+			break;
+		}
+		if (ch == '?' || ch == ':') {
+			printf("error\n");
+			return 0; /* discard the output */
+		}
+		if (ch >= 256) {
+			fprintf(out, "opt:%s", longopts[ch - 256].name);
+		} else {
+			fprintf(out, "opt:%c", ch);
+		}
+		if (optarg != NULL) {
+			fprintf(out, "=%s", optarg);
+		}
+		fprintf(out, "\n");
+	}
+	for (int idx = optind; idx < argc; idx++) {
+		fprintf(out, "arg:%s\n", argv[idx]);
+	}
+	fclose(out);
+	fputs(output, stdout);
+	free(output);
+	return 0;
+}
@@ -0,0 +1,99 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bassosimone/flagscanner"
+)
+
+// ErrOptionValueNotAllowed indicates that an option was explicitly
+// given a value not included in the [*Option] AllowedValues.
+type ErrOptionValueNotAllowed struct {
+	// Option is the offending option
+	Option *Option
+
+	// Value is the value that is not allowed
+	Value string
+
+	// AllowedValues contains the allowed values
+	AllowedValues []string
+
+	// Token is the related token
+	Token flagscanner.Token
+}
+
+var _ error = ErrOptionValueNotAllowed{}
+
+// Error returns a string representation of this error.
+func (err ErrOptionValueNotAllowed) Error() string {
+	return fmt.Sprintf("invalid value for option %s%s: %q (allowed values: %s)",
+		err.Option.Prefix, err.Option.Name, err.Value, strings.Join(err.AllowedValues, ", "))
+}
+
+// ErrOptionValueInvalid indicates that the [*Option] Validator
+// rejected the value explicitly passed to an option.
+type ErrOptionValueInvalid struct {
+	// Option is the offending option
+	Option *Option
+
+	// Value is the invalid value
+	Value string
+
+	// Token is the related token
+	Token flagscanner.Token
+
+	// Err is the error returned by the validator
+	Err error
+}
+
+var _ error = ErrOptionValueInvalid{}
+
+// Error returns a string representation of this error.
+func (err ErrOptionValueInvalid) Error() string {
+	return fmt.Sprintf("invalid value for option %s%s: %q: %s",
+		err.Option.Prefix, err.Option.Name, err.Value, err.Err.Error())
+}
+
+// Unwrap returns the error returned by the validator.
+func (err ErrOptionValueInvalid) Unwrap() error {
+	return err.Err
+}
+
+// validateOptionValue ensures that an explicit option value is acceptable.
+func validateOptionValue(value ValueOption) error {
+	// Default values are chosen by the programmer, so we only validate
+	// the values explicitly provided by the user
+	if !value.Explicit {
+		return nil
+	}
+
+	// Make sure the value is one of the allowed values, if any
+	option := value.Option
+	if len(option.AllowedValues) > 0 && !slices.Contains(option.AllowedValues, value.Value) {
+		return ErrOptionValueNotAllowed{
+			Option:        option,
+			Value:         value.Value,
+			AllowedValues: option.AllowedValues,
+			Token:         value.Tok,
+		}
+	}
+
+	// Run the custom validator, if any
+	if option.Validator != nil {
+		if err := option.Validator(value.Value); err != nil {
+			return ErrOptionValueInvalid{
+				Option: option,
+				Value:  value.Value,
+				Token:  value.Tok,
+				Err:    err,
+			}
+		}
+	}
+	return nil
+}
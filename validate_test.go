@@ -0,0 +1,209 @@
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+
+package flagparser
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/bassosimone/flagscanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrOptionValueNotAllowed(t *testing.T) {
+	err := ErrOptionValueNotAllowed{
+		Option: &Option{
+			Prefix: "--",
+			Name:   "compress",
+			Type:   OptionTypeStandaloneArgumentOptional,
+		},
+		Value:         "lz4",
+		AllowedValues: []string{"gzip", "bzip2", "xz"},
+		Token: flagscanner.OptionToken{
+			Idx:    4,
+			Prefix: "--",
+			Name:   "compress=lz4",
+		},
+	}
+
+	expect := `invalid value for option --compress: "lz4" (allowed values: gzip, bzip2, xz)`
+	assert.Equal(t, expect, err.Error())
+}
+
+func TestErrOptionValueInvalid(t *testing.T) {
+	cause := errors.New("out of range")
+	err := ErrOptionValueInvalid{
+		Option: &Option{
+			Prefix: "-",
+			Name:   "p",
+			Type:   OptionTypeGroupableArgumentRequired,
+		},
+		Value: "99999",
+		Token: flagscanner.OptionToken{
+			Idx:    2,
+			Prefix: "-",
+			Name:   "p99999",
+		},
+		Err: cause,
+	}
+
+	expect := `invalid value for option -p: "99999": out of range`
+	assert.Equal(t, expect, err.Error())
+	assert.True(t, errors.Is(err, cause))
+}
+
+func Test_validateOptionValue(t *testing.T) {
+	option := &Option{
+		DefaultValue:  "none",
+		AllowedValues: []string{"gzip", "bzip2", "xz"},
+		Prefix:        "--",
+		Name:          "compress",
+		Type:          OptionTypeStandaloneArgumentOptional,
+	}
+	tok := flagscanner.OptionToken{Idx: 1, Prefix: "--", Name: "compress"}
+
+	t.Run("allowed explicit value", func(t *testing.T) {
+		err := validateOptionValue(ValueOption{Option: option, Tok: tok, Value: "xz", Explicit: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("default value is not checked", func(t *testing.T) {
+		err := validateOptionValue(ValueOption{Option: option, Tok: tok, Value: "none", Explicit: false})
+		assert.NoError(t, err)
+	})
+
+	t.Run("disallowed explicit value", func(t *testing.T) {
+		err := validateOptionValue(ValueOption{Option: option, Tok: tok, Value: "lz4", Explicit: true})
+		var errvalue ErrOptionValueNotAllowed
+		if assert.True(t, errors.As(err, &errvalue)) {
+			assert.Equal(t, option, errvalue.Option)
+			assert.Equal(t, "lz4", errvalue.Value)
+			assert.Equal(t, []string{"gzip", "bzip2", "xz"}, errvalue.AllowedValues)
+			assert.Equal(t, tok, errvalue.Token)
+		}
+	})
+
+	t.Run("validator runs after allowed values", func(t *testing.T) {
+		var called []string
+		other := &Option{
+			AllowedValues: []string{"gzip", "xz"},
+			Validator: func(value string) error {
+				called = append(called, value)
+				return errors.New("unsupported")
+			},
+			Prefix: "--",
+			Name:   "compress",
+			Type:   OptionTypeStandaloneArgumentRequired,
+		}
+
+		err := validateOptionValue(ValueOption{Option: other, Tok: tok, Value: "lz4", Explicit: true})
+		var notAllowed ErrOptionValueNotAllowed
+		assert.True(t, errors.As(err, &notAllowed))
+		assert.Empty(t, called)
+
+		err = validateOptionValue(ValueOption{Option: other, Tok: tok, Value: "xz", Explicit: true})
+		var invalid ErrOptionValueInvalid
+		if assert.True(t, errors.As(err, &invalid)) {
+			assert.Equal(t, other, invalid.Option)
+			assert.Equal(t, "xz", invalid.Value)
+			assert.Equal(t, tok, invalid.Token)
+			assert.EqualError(t, invalid.Err, "unsupported")
+		}
+		assert.Equal(t, []string{"xz"}, called)
+	})
+
+	t.Run("any value is allowed without allowed values", func(t *testing.T) {
+		other := &Option{Prefix: "--", Name: "output", Type: OptionTypeStandaloneArgumentRequired}
+		err := validateOptionValue(ValueOption{Option: other, Tok: tok, Value: "lz4", Explicit: true})
+		assert.NoError(t, err)
+	})
+}
+
+func TestParser_ParseAllowedValues(t *testing.T) {
+	px := NewParser()
+	px.AddOption(
+		&Option{
+			AllowedValues: []string{"4", "6"},
+			Prefix:        "-",
+			Name:          "f",
+			Type:          OptionTypeGroupableArgumentRequired,
+		},
+		&Option{
+			DefaultValue:  "gzip",
+			AllowedValues: []string{"gzip", "xz"},
+			Prefix:        "--",
+			Name:          "compress",
+			Type:          OptionTypeStandaloneArgumentOptional,
+		},
+	)
+
+	t.Run("success", func(t *testing.T) {
+		values, err := px.Parse([]string{"-f6", "--compress", "--compress=xz"})
+		assert.NoError(t, err)
+		assert.Len(t, values, 3)
+	})
+
+	t.Run("groupable failure", func(t *testing.T) {
+		_, err := px.Parse([]string{"-f", "5"})
+		assert.EqualError(t, err, `invalid value for option -f: "5" (allowed values: 4, 6)`)
+	})
+
+	t.Run("standalone failure", func(t *testing.T) {
+		_, err := px.Parse([]string{"--compress=lz4"})
+		assert.EqualError(t, err, `invalid value for option --compress: "lz4" (allowed values: gzip, xz)`)
+	})
+}
+
+func TestParser_ParseValidator(t *testing.T) {
+	validatePort := func(value string) error {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if port <= 0 || port > 65535 {
+			return errors.New("port out of range")
+		}
+		return nil
+	}
+
+	px := NewParser()
+	px.AddOption(
+		&Option{
+			Validator: validatePort,
+			Prefix:    "-",
+			Name:      "p",
+			Type:      OptionTypeGroupableArgumentRequired,
+		},
+		&Option{
+			Validator: validatePort,
+			Prefix:    "--",
+			Name:      "port",
+			Type:      OptionTypeStandaloneArgumentRequired,
+		},
+	)
+
+	t.Run("success", func(t *testing.T) {
+		values, err := px.Parse([]string{"-p53", "--port", "443"})
+		assert.NoError(t, err)
+		assert.Len(t, values, 2)
+	})
+
+	t.Run("groupable failure", func(t *testing.T) {
+		_, err := px.Parse([]string{"-p", "99999"})
+		var errvalue ErrOptionValueInvalid
+		if assert.True(t, errors.As(err, &errvalue)) {
+			assert.Equal(t, px.Options[0], errvalue.Option)
+			assert.Equal(t, flagscanner.OptionToken{Idx: 0, Prefix: "-", Name: "p"}, errvalue.Token)
+		}
+		assert.EqualError(t, err, `invalid value for option -p: "99999": port out of range`)
+	})
+
+	t.Run("standalone failure", func(t *testing.T) {
+		_, err := px.Parse([]string{"--port=http"})
+		var numErr *strconv.NumError
+		assert.True(t, errors.As(err, &numErr))
+	})
+}
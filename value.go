@@ -48,7 +48,23 @@ type ValueOption struct {
 	//	6. For [OptionTypeStandaloneArgumentOptional] this field
 	// 	   contains the value of the parsed argument, if any,
 	// 	   or the default value specified in [*Option], otherwise.
+	// 	   See also the [*Option] KeepEmptyValue field.
+	//
+	//	7. For [OptionTypeGroupableArgumentOptional] this field
+	// 	   contains the value of the parsed argument, if any,
+	// 	   or the default value specified in [*Option], otherwise.
 	Value string
+
+	// Explicit is true when the user explicitly provided the value
+	// (e.g., `--output FILE` or `--http=2.0`) and false when the option
+	// takes no argument or the value is the [*Option] DefaultValue
+	// because the argument was omitted (e.g., `--http` or `--http=`).
+	Explicit bool
+
+	// Delimiter is the delimiter between the name of a standalone option and
+	// its value that Strings uses (e.g., `:` for `/out:FILE`). We copy it from
+	// the [*Parser] OptionValueDelimiter field. When empty, we use `=`.
+	Delimiter string
 }
 
 var _ Value = ValueOption{}
@@ -61,7 +77,27 @@ func (val ValueOption) Strings() []string {
 		output = append(output, val.Option.Prefix+val.Option.Name)
 
 	case OptionTypeStandaloneArgumentOptional:
-		output = append(output, val.Option.Prefix+val.Option.Name+"="+val.Value)
+		// Omit the default value unless explicit, such that parsing
+		// again produces an equivalent non-explicit value. When the option
+		// may take the next argument, use an empty argument to prevent it
+		// from consuming a positional argument (e.g., `--color=` `always`).
+		if !val.Explicit && val.Value == val.Option.DefaultValue {
+			if val.Option.SeparateArgument != nil {
+				output = append(output, val.Option.Prefix+val.Option.Name+val.delimiter())
+				break
+			}
+			output = append(output, val.Option.Prefix+val.Option.Name)
+			break
+		}
+		output = append(output, val.Option.Prefix+val.Option.Name+val.delimiter()+val.Value)
+
+	case OptionTypeGroupableArgumentOptional:
+		// Like above but the argument is glued to the option name.
+		if !val.Explicit && val.Value == val.Option.DefaultValue {
+			output = append(output, val.Option.Prefix+val.Option.Name)
+			break
+		}
+		output = append(output, val.Option.Prefix+val.Option.Name+val.Value)
 
 	case OptionTypeStandaloneArgumentRequired, OptionTypeGroupableArgumentRequired:
 		output = append(output, val.Option.Prefix+val.Option.Name)
@@ -73,6 +109,14 @@ func (val ValueOption) Strings() []string {
 	return output
 }
 
+// delimiter returns the delimiter between the option name and its value.
+func (val ValueOption) delimiter() string {
+	if val.Delimiter == "" {
+		return "="
+	}
+	return val.Delimiter
+}
+
 // Token implements [Value].
 func (val ValueOption) Token() flagscanner.Token {
 	return val.Tok
@@ -85,6 +129,10 @@ type ValuePositionalArgument struct {
 
 	// Value is the argument value.
 	Value string
+
+	// Argument is the named slot to which we assigned the argument
+	// or nil when the [*Parser] PositionalArguments field is empty.
+	Argument *PositionalArgument
 }
 
 var _ Value = ValuePositionalArgument{}
@@ -93,6 +93,57 @@ func TestValue(t *testing.T) {
 			panics:  false,
 		},
 
+		{
+			name: "OptionTypeStandaloneArgumentOptional_default",
+			input: ValueOption{
+				Tok: testToken,
+				Option: &Option{
+					DefaultValue: "antani",
+					Prefix:       "--",
+					Name:         "verbose",
+					Type:         OptionTypeStandaloneArgumentOptional,
+				},
+				Value: "antani",
+			},
+			strings: []string{"--verbose"},
+			panics:  false,
+		},
+
+		{
+			name: "OptionTypeStandaloneArgumentOptional_explicitDefault",
+			input: ValueOption{
+				Tok: testToken,
+				Option: &Option{
+					DefaultValue: "antani",
+					Prefix:       "--",
+					Name:         "verbose",
+					Type:         OptionTypeStandaloneArgumentOptional,
+				},
+				Value:    "antani",
+				Explicit: true,
+			},
+			strings: []string{"--verbose=antani"},
+			panics:  false,
+		},
+
+		{
+			name: "OptionTypeStandaloneArgumentOptional_explicitEmpty",
+			input: ValueOption{
+				Tok: testToken,
+				Option: &Option{
+					DefaultValue:   "antani",
+					KeepEmptyValue: true,
+					Prefix:         "--",
+					Name:           "verbose",
+					Type:           OptionTypeStandaloneArgumentOptional,
+				},
+				Value:    "",
+				Explicit: true,
+			},
+			strings: []string{"--verbose="},
+			panics:  false,
+		},
+
 		{
 			name: "OptionTypeStandaloneArgumentRequired",
 			input: ValueOption{